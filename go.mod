@@ -8,7 +8,7 @@ require (
 	github.com/mattn/go-runewidth v0.0.12
 	github.com/rivo/tview v0.0.0-20210521091241-1fd4a5b7aab3
 	github.com/rivo/uniseg v0.2.0
-	golang.org/x/sys v0.0.0-20210521203332-0cec03c779c1 // indirect
+	golang.org/x/sys v0.0.0-20210521203332-0cec03c779c1
 	golang.org/x/term v0.0.0-20210503060354-a79de5458b56 // indirect
 	golang.org/x/text v0.3.6
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
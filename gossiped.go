@@ -3,11 +3,13 @@ package main
 import (
 	"github.com/askovpen/gossiped/pkg/areasconfig"
 	"github.com/askovpen/gossiped/pkg/config"
+	"github.com/askovpen/gossiped/pkg/msgapi"
 	"github.com/askovpen/gossiped/pkg/ui"
 	"github.com/askovpen/gossiped/pkg/utils"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 var (
@@ -15,6 +17,13 @@ var (
 	commit  = "dev"
 )
 
+// usage commands help
+const usage = `Usage: %s [toss|scandupes|pack|sqfix] <config.yml>
+  toss       toss inbound packets
+  scandupes  report dupes in areas
+  pack       purge and compact jam, squish and msg areas, reclaims free space
+  sqfix      check and rebuild squish index, does not compact, run pack after it`
+
 func tryFindConfig() string {
 	for _, fn := range []string{
 		filepath.Join(os.Getenv("HOME"), "gossiped.yml"),
@@ -38,17 +47,23 @@ func main() {
 	config.InitVars()
 	log.Printf("%s started", config.LongPID)
 	var fn string
-	if len(os.Args) == 1 {
+	args := os.Args[1:]
+	cmd := ""
+	if len(args) > 0 && (args[0] == "toss" || args[0] == "scandupes" || args[0] == "pack" || args[0] == "sqfix") {
+		cmd = args[0]
+		args = args[1:]
+	}
+	if len(args) == 0 {
 		fn = tryFindConfig()
 		if fn == "" {
-			log.Printf("Usage: %s <config.yml>", os.Args[0])
+			log.Printf(usage, os.Args[0])
 			return
 		}
 	} else {
-		if utils.FileExists(os.Args[1]) {
-			fn = os.Args[1]
+		if utils.FileExists(args[0]) {
+			fn = args[0]
 		} else {
-			log.Printf("Usage: %s <config.yml>", os.Args[0])
+			log.Printf(usage, os.Args[0])
 			return
 		}
 	}
@@ -67,6 +82,82 @@ func main() {
 		log.Print(err)
 		return
 	}
+	msgapi.IndexPath = config.Config.Index.Path
+	if msgapi.IndexPath == "" {
+		msgapi.IndexPath = filepath.Join(filepath.Dir(fn), "index")
+	}
+	if config.Config.Dupebase.Path != "" {
+		msgapi.Dupes, err = msgapi.OpenDupeDB(config.Config.Dupebase.Path, time.Duration(config.Config.Dupebase.Days)*24*time.Hour)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+	}
+	if cmd == "scandupes" {
+		for _, a := range msgapi.Areas {
+			dupes, err := msgapi.ScanDupes(a)
+			if err != nil {
+				log.Printf("%s: %s", a.GetName(), err)
+				continue
+			}
+			if len(dupes) > 0 {
+				log.Printf("%s: %d dupes %v", a.GetName(), len(dupes), dupes)
+			}
+		}
+		return
+	}
+	if cmd == "pack" {
+		for _, a := range msgapi.Areas {
+			p, ok := a.(msgapi.Packer)
+			if !ok {
+				continue
+			}
+			stats, err := p.Pack(msgapi.ConfigPackOptions())
+			if err != nil {
+				log.Printf("%s: %s", a.GetName(), err)
+				continue
+			}
+			log.Printf("%s: packed %d -> %d msgs, %d deleted, %d purged",
+				a.GetName(), stats.Before, stats.After, stats.Deleted, stats.Purged)
+		}
+		return
+	}
+	if cmd == "sqfix" {
+		for _, a := range msgapi.Areas {
+			s, ok := a.(*msgapi.Squish)
+			if !ok {
+				continue
+			}
+			report, err := s.Check()
+			if err != nil {
+				log.Printf("%s: %s", a.GetName(), err)
+				continue
+			}
+			if len(report.Problems) == 0 {
+				continue
+			}
+			for _, p := range report.Problems {
+				log.Printf("%s: %s", a.GetName(), p)
+			}
+			report, err = s.RebuildIndex()
+			if err != nil {
+				log.Printf("%s: %s", a.GetName(), err)
+				continue
+			}
+			log.Printf("%s: rebuilt, %d msgs, %d free frames", a.GetName(), report.Messages, report.Free)
+		}
+		return
+	}
+	if cmd == "toss" {
+		stats, err := msgapi.Toss(config.Config.Inbound.Path)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		log.Printf("tossed %d packets: %d netmail, %d echomail, %d bad, %d dupes",
+			stats.Packets, stats.Netmail, stats.Echomail, stats.Bad, stats.Dupes)
+		return
+	}
 	// ui.App, err = gocui.NewGui(gocui.OutputNormal)
 	app := ui.NewApp()
 	log.Print("start")
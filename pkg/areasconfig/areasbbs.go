@@ -2,10 +2,12 @@ package areasconfig
 
 import (
 	"bufio"
+	"github.com/askovpen/gossiped/pkg/config"
 	"github.com/askovpen/gossiped/pkg/msgapi"
 	"io/ioutil"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -25,6 +27,11 @@ func areasbbsConfigRead(fn string) error {
 		if len(res) < 2 {
 			continue
 		}
+		if board, err := strconv.ParseUint(res[0], 10, 8); err == nil && board > 0 && board <= 200 {
+			area := &msgapi.Hudson{AreaName: res[1], AreaPath: config.Config.Hudson.Path, AreaType: msgapi.EchoAreaTypeEcho, Board: uint8(board), UserRecord: config.Config.Hudson.User}
+			msgapi.Areas = append(msgapi.Areas, area)
+			continue
+		}
 		if len(res[0]) < 3 {
 			continue
 		}
@@ -80,6 +80,28 @@ func getArea(i int) (msgapi.AreaPrimitive, error) {
 			r.Chrs = config.Config.Areas[i].Chrs
 		}
 		return r, nil
+	case "hudson":
+		path := config.Config.Areas[i].Path
+		if path == "" {
+			path = config.Config.Hudson.Path
+		}
+		r := &msgapi.Hudson{AreaName: config.Config.Areas[i].Name, AreaPath: path, AreaType: getType(config.Config.Areas[i].Type), Board: config.Config.Areas[i].Board, UserRecord: config.Config.Hudson.User}
+		if config.Config.Areas[i].Chrs != "" {
+			r.Chrs = config.Config.Areas[i].Chrs
+		}
+		return r, nil
+	case "smb":
+		r := &msgapi.SMB{AreaName: config.Config.Areas[i].Name, AreaPath: config.Config.Areas[i].Path, AreaType: getType(config.Config.Areas[i].Type)}
+		if config.Config.Areas[i].Chrs != "" {
+			r.Chrs = config.Config.Areas[i].Chrs
+		}
+		return r, nil
+	case "pkt":
+		r := &msgapi.PKT{AreaName: config.Config.Areas[i].Name, AreaPath: config.Config.Areas[i].Path, AreaType: getType(config.Config.Areas[i].Type)}
+		if config.Config.Areas[i].Chrs != "" {
+			r.Chrs = config.Config.Areas[i].Chrs
+		}
+		return r, nil
 	}
 	return nil, errors.New("uknown type")
 }
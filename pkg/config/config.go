@@ -21,11 +21,50 @@ type configS struct {
 		Type     string
 		BaseType string
 		Chrs     string
+		Board    uint8
+	}
+	Hudson struct {
+		Path string
+		User uint16
+	}
+	Dupebase struct {
+		Path string
+		Days int
+	}
+	Index struct {
+		Path string
+	}
+	Purge struct {
+		Days     int
+		MaxMsgs  uint32
+		Renumber bool
+	}
+	Spell struct {
+		Path  string
+		Langs []string
+	}
+	Inbound struct {
+		Path string
+	}
+	Editor struct {
+		OSC52    bool
+		Keys     map[string]string
+		Margin   int
+		NoWrap   bool
+		NoReflow bool
+		External bool
+		Command  string
+	}
+	Outbound struct {
+		Path     string
+		Uplink   *types.FidoAddr
+		Password string
 	}
 	Log      string
 	Address  *types.FidoAddr
 	Origin   string
 	Tearline string
+	Tagline  string
 	Template string
 	Chrs     struct {
 		Default string
@@ -65,22 +104,33 @@ func Read(fn string) error {
 	if Config.Chrs.Default == "" {
 		return errors.New("Config.Chrs.Default not defined")
 	}
-	tpl, err := ioutil.ReadFile(Config.Template)
+	Template, err = ReadTemplate(Config.Template)
 	if err != nil {
 		return err
 	}
-	for _, l := range strings.Split(string(tpl), "\n") {
-		if len(l) > 0 && l[0] == ';' {
-			continue
-		}
-		Template = append(Template, l)
-	}
 	if len(Config.Tearline) == 0 {
 		Config.Tearline = LongPID
 	}
 	readCity()
 	return nil
 }
+
+// ReadTemplate read GoldED template lines without comments
+func ReadTemplate(fn string) ([]string, error) {
+	tpl, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, l := range strings.Split(strings.Replace(string(tpl), "\r\n", "\n", -1), "\n") {
+		if len(l) > 0 && l[0] == ';' {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
 func readCity() {
 	yamlFile, err := ioutil.ReadFile("city.yaml")
 	if err != nil {
@@ -26,6 +26,15 @@ func (g Group) String() string {
 	return ""
 }
 
+// GetGroup returns the group with the given name, defining it if needed
+func GetGroup(name string) Group {
+	if _, ok := Groups[name]; !ok {
+		numGroups++
+		Groups[name] = numGroups
+	}
+	return Groups[name]
+}
+
 // A Def is a full syntax definition for a language
 // It has a filetype, information about how to detect the filetype based
 // on filename or header (the first line of the file)
@@ -220,12 +229,7 @@ func parseRules(input []interface{}, curRegion *region) (ru *rules, err error) {
 					return nil, err
 				}
 
-				groupStr := group
-				if _, ok := Groups[groupStr]; !ok {
-					numGroups++
-					Groups[groupStr] = numGroups
-				}
-				groupNum := Groups[groupStr]
+				groupNum := GetGroup(group)
 				ru.patterns = append(ru.patterns, &pattern{groupNum, r})
 			default:
 				return nil, fmt.Errorf("bad type %T", object)
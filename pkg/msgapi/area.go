@@ -20,6 +20,9 @@ const (
 	EchoAreaMsgTypeJAM        EchoAreaMsgType = "JAM"
 	EchoAreaMsgTypeMSG        EchoAreaMsgType = "MSG"
 	EchoAreaMsgTypeSquish     EchoAreaMsgType = "Squish"
+	EchoAreaMsgTypeHudson     EchoAreaMsgType = "Hudson"
+	EchoAreaMsgTypeSMB        EchoAreaMsgType = "SMB"
+	EchoAreaMsgTypePKT        EchoAreaMsgType = "PKT"
 	EchoAreaMsgTypePasstrough EchoAreaMsgType = "Passtrough"
 	EchoAreaTypeNetmail       EchoAreaType    = 0
 	EchoAreaTypeEcho          EchoAreaType    = 3
@@ -46,6 +49,53 @@ type AreaPrimitive interface {
 	GetMessages() *[]MessageListItem
 }
 
+// Packer area supporting pack and purge
+type Packer interface {
+	Pack(PackOptions) (PackStats, error)
+}
+
+// Detached return new instance over the same message base with nothing loaded,
+// background readers use it instead of shared area from Areas, whose lazily
+// loaded index is not safe for concurrent use
+func Detached(area AreaPrimitive) AreaPrimitive {
+	switch a := area.(type) {
+	case *JAM:
+		return &JAM{AreaPath: a.AreaPath, AreaName: a.AreaName, AreaType: a.AreaType, Chrs: a.Chrs}
+	case *Squish:
+		return &Squish{AreaPath: a.AreaPath, AreaName: a.AreaName, AreaType: a.AreaType, Chrs: a.Chrs}
+	case *MSG:
+		return &MSG{AreaPath: a.AreaPath, AreaName: a.AreaName, AreaType: a.AreaType, Chrs: a.Chrs}
+	case *Hudson:
+		return &Hudson{AreaPath: a.AreaPath, AreaName: a.AreaName, AreaType: a.AreaType, Chrs: a.Chrs,
+			Board: a.Board, UserRecord: a.UserRecord}
+	case *SMB:
+		return &SMB{AreaPath: a.AreaPath, AreaName: a.AreaName, AreaType: a.AreaType, Chrs: a.Chrs}
+	case *PKT:
+		return &PKT{AreaPath: a.AreaPath, AreaName: a.AreaName, AreaType: a.AreaType, Chrs: a.Chrs}
+	}
+	return area
+}
+
+// Reload drop index and messages loaded by area, so they are read again after
+// base was changed through Detached instance
+func Reload(area AreaPrimitive) {
+	switch a := area.(type) {
+	case *JAM:
+		a.indexStructure, a.idxRecords, a.lastRead, a.messages = nil, 0, nil, nil
+	case *Squish:
+		a.indexStructure, a.messages = nil, nil
+	case *MSG:
+		a.messageNums, a.messages = nil, nil
+	case *Hudson:
+		a.indexStructure, a.messages = nil, nil
+	case *SMB:
+		a.indexStructure, a.messages = nil, nil
+	case *PKT:
+		a.index, a.messages = nil, nil
+	}
+	forgetThread(area)
+}
+
 // Lookup name->id
 func Lookup(name string) int {
 	for i, a := range Areas {
@@ -0,0 +1,89 @@
+package msgapi
+
+import (
+	"strings"
+)
+
+// NetmailAttrs attributes editable in netmail header
+var NetmailAttrs = []string{"Pvt", "Cra", "Hld", "K/s", "Att", "Rrq", "Dir"}
+
+// attributes without FTS-0001 bit, stored in FLAGS kludge
+var flagsAttrs = []struct {
+	attr, flag string
+}{
+	{"Dir", "DIR"},
+	{"Imm", "IMM"},
+}
+
+// jamAttrs JAM attribute names by bit
+var jamAttrs = []string{
+	"Loc", "Trs", "Pvt", "Rcv",
+	"Snt", "K/s", "Arc", "Hld",
+	"Cra", "Imm", "Dir", "Gat",
+	"Frq", "Att", "Tfs", "Kfs",
+	"Rrq", "Cpt", "Orp", "Enc",
+	"Cmp", "Esc", "Fpu", "",
+	"", "", "", "",
+	"", "", "", "[red]Del[silver]",
+}
+
+// JAM message types
+const (
+	jamTypeLocal = 0x00800000
+	jamTypeEcho  = 0x01000000
+	jamTypeNet   = 0x02000000
+)
+
+func getJamAttrNum(attrs []string) (a uint32) {
+	for _, attr := range attrs {
+		for i, d := range jamAttrs[:31] {
+			if d != "" && d == attr {
+				a |= 1 << uint(i)
+			}
+		}
+	}
+	return
+}
+
+// HasAttr check message attribute
+func (m *Message) HasAttr(attr string) bool {
+	for _, a := range m.Attrs {
+		if a == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// ToggleAttr set or clear message attribute
+func (m *Message) ToggleAttr(attr string) {
+	for i, a := range m.Attrs {
+		if a == attr {
+			m.Attrs = append(m.Attrs[:i], m.Attrs[i+1:]...)
+			return
+		}
+	}
+	m.Attrs = append(m.Attrs, attr)
+}
+
+// flagsKludge FLAGS kludge value for attributes without native bit
+func (m *Message) flagsKludge() string {
+	var flags []string
+	for _, f := range flagsAttrs {
+		if m.HasAttr(f.attr) {
+			flags = append(flags, f.flag)
+		}
+	}
+	return strings.Join(flags, " ")
+}
+
+// parseFlags add attributes from FLAGS kludge
+func (m *Message) parseFlags(flags string) {
+	for _, fl := range strings.Fields(flags) {
+		for _, f := range flagsAttrs {
+			if strings.EqualFold(fl, f.flag) && !m.HasAttr(f.attr) {
+				m.Attrs = append(m.Attrs, f.attr)
+			}
+		}
+	}
+}
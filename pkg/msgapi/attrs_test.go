@@ -0,0 +1,68 @@
+package msgapi
+
+import (
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNetmailAttrs(t *testing.T) {
+	areas := []AreaPrimitive{
+		&MSG{AreaPath: "../../testdata/attrmsg", AreaName: "attrmsg", AreaType: EchoAreaTypeNetmail},
+		&Squish{AreaPath: "../../testdata/attrsq", AreaName: "attrsq", AreaType: EchoAreaTypeNetmail},
+		&JAM{AreaPath: "../../testdata/attrjam", AreaName: "attrjam", AreaType: EchoAreaTypeNetmail},
+		&Hudson{AreaPath: "../../testdata/attrhudson", AreaName: "attrhudson", AreaType: EchoAreaTypeNetmail, Board: 1},
+	}
+	attrs := [][]string{
+		{"Pvt", "Cra", "Att", "Loc", "Hld", "Dir"},
+		{"Pvt", "Cra", "Att", "Loc", "Hld", "Dir"},
+		{"Pvt", "Cra", "Att", "Loc", "Hld", "Dir"},
+		{"Pvt", "Cra", "Att", "Loc", "Rrq", "Dir"},
+	}
+	Areas = Areas[:0]
+	Areas = append(Areas, areas...)
+	g := Goblin(t)
+	g.Describe("Check netmail attributes", func() {
+		g.It("toggle", func() {
+			m := &Message{Attrs: []string{"Pvt", "Loc"}}
+			m.ToggleAttr("Pvt")
+			m.ToggleAttr("Cra")
+			g.Assert(m.Attrs).Equal([]string{"Loc", "Cra"})
+			g.Assert(m.HasAttr("Cra")).IsTrue()
+			g.Assert(m.HasAttr("Pvt")).IsFalse()
+		})
+		for i, area := range areas {
+			id, area := i, area
+			g.It("round-trip "+area.GetName(), func() {
+				m := &Message{
+					AreaID:      id,
+					From:        "SysOp",
+					To:          "SysOp",
+					Subject:     "Test",
+					FromAddr:    types.AddrFromNum(2, 5020, 9696, 1),
+					ToAddr:      types.AddrFromNum(2, 5020, 9696, 2),
+					DateWritten: time.Now(),
+					DateArrived: time.Now(),
+					Body:        "Test\nBody",
+					Kludges:     make(map[string]string),
+					Attrs:       append([]string(nil), attrs[id]...),
+				}
+				m.MakeBody()
+				g.Assert(m.Kludges["FLAGS"]).Equal("DIR")
+				g.Assert(area.SaveMsg(m)).Equal(nil)
+				area.GetCount()
+				nm, err := area.GetMsg(1)
+				g.Assert(err).Equal(nil)
+				for _, attr := range attrs[id] {
+					g.Assert(nm.HasAttr(attr)).IsTrue()
+				}
+				g.Assert(nm.HasAttr("K/s")).IsFalse()
+			})
+		}
+	})
+	for _, fn := range []string{"attrmsg", "attrsq.sqd", "attrsq.sqi", "attrsq.sql", "attrjam.jhr", "attrjam.jdt", "attrjam.jdx", "attrjam.jlr", "attrhudson"} {
+		os.RemoveAll("../../testdata/" + fn)
+	}
+}
@@ -0,0 +1,173 @@
+package msgapi
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"github.com/askovpen/gossiped/pkg/utils"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// DupeDB persistent dupe database
+type DupeDB struct {
+	Path      string
+	Retention time.Duration
+	entries   map[dupeKey]uint32
+}
+
+type dupeKey struct {
+	AreaCRC, MsgIDCRC, HdrCRC uint32
+}
+
+type dupeS struct {
+	AreaCRC, MsgIDCRC, HdrCRC, Time uint32
+}
+
+// ErrDupe returned by SaveMsg on duplicate message
+var ErrDupe = errors.New("duplicate message")
+
+// Dupes global dupe database, nil if disabled
+var Dupes *DupeDB
+
+func getDupeKey(area string, m *Message) dupeKey {
+	hdr := m.From + "\x00" + m.To + "\x00" + m.Subject + "\x00" + m.DateWritten.Format("02 Jan 06  15:04:05")
+	k := dupeKey{
+		AreaCRC: crc32.ChecksumIEEE([]byte(strings.ToLower(area))),
+		HdrCRC:  crc32.ChecksumIEEE([]byte(hdr)),
+	}
+	if msgid, ok := m.Kludges["MSGID:"]; ok && msgid != "" {
+		k.MsgIDCRC = crc32.ChecksumIEEE([]byte(msgid))
+	}
+	return k
+}
+
+// OpenDupeDB open dupe database, expired entries are dropped
+func OpenDupeDB(path string, retention time.Duration) (*DupeDB, error) {
+	d := &DupeDB{Path: path, Retention: retention, entries: make(map[dupeKey]uint32)}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return d, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	part := make([]byte, 16*1024)
+	expired := 0
+	now := uint32(time.Now().Unix())
+	for {
+		count, err := reader.Read(part)
+		if err != nil {
+			break
+		}
+		partb := bytes.NewBuffer(part[:count])
+		for {
+			var rec dupeS
+			if err = utils.ReadStructFromBuffer(partb, &rec); err != nil {
+				break
+			}
+			if retention > 0 && now-rec.Time > uint32(retention.Seconds()) {
+				expired++
+				continue
+			}
+			d.entries[dupeKey{rec.AreaCRC, rec.MsgIDCRC, rec.HdrCRC}] = rec.Time
+		}
+	}
+	file.Close()
+	if expired > 0 {
+		return d, d.write()
+	}
+	return d, nil
+}
+
+func (d *DupeDB) write() error {
+	buf := new(bytes.Buffer)
+	for k, t := range d.entries {
+		rec := dupeS{k.AreaCRC, k.MsgIDCRC, k.HdrCRC, t}
+		if err := utils.WriteStructToBuffer(buf, &rec); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(d.Path, buf.Bytes(), 0644)
+}
+
+// Check return true if message already seen in area
+func (d *DupeDB) Check(area string, m *Message) bool {
+	_, ok := d.entries[getDupeKey(area, m)]
+	return ok
+}
+
+// Add add message to database
+func (d *DupeDB) Add(area string, m *Message) error {
+	return d.addKey(getDupeKey(area, m))
+}
+
+func (d *DupeDB) addKey(k dupeKey) error {
+	if _, ok := d.entries[k]; ok {
+		return nil
+	}
+	rec := dupeS{k.AreaCRC, k.MsgIDCRC, k.HdrCRC, uint32(time.Now().Unix())}
+	d.entries[k] = rec.Time
+	buf := new(bytes.Buffer)
+	if err := utils.WriteStructToBuffer(buf, &rec); err != nil {
+		return err
+	}
+	_, err := appendFile(d.Path, buf.Bytes())
+	return err
+}
+
+// dupeChecked reports whether saves into area are checked, dupe and bad areas keep every copy
+func dupeChecked(t EchoAreaType) bool {
+	return Dupes != nil && t != EchoAreaTypeDupe && t != EchoAreaTypeBad
+}
+
+// checkDupe consult dupe database before save, the key is taken from decoded
+// header, before SaveMsg encodes it to area charset, and passed to addDupe
+func checkDupe(area string, t EchoAreaType, tm *Message) (dupeKey, error) {
+	k := getDupeKey(area, tm)
+	if dupeChecked(t) {
+		if _, ok := Dupes.entries[k]; ok {
+			return k, ErrDupe
+		}
+	}
+	return k, nil
+}
+
+// addDupe record message key in dupe database after successful save, deferred with SaveMsg result
+func addDupe(t EchoAreaType, k dupeKey, err *error) {
+	if *err != nil || !dupeChecked(t) {
+		return
+	}
+	*err = Dupes.addKey(k)
+}
+
+// ScanDupes return numbers of messages duplicating earlier ones in area, found messages added to Dupes
+func ScanDupes(area AreaPrimitive) ([]uint32, error) {
+	var ret []uint32
+	seen := make(map[dupeKey]bool)
+	for i := uint32(1); i <= area.GetCount(); i++ {
+		m, err := area.GetMsg(i)
+		if err != nil {
+			return ret, err
+		}
+		if m == nil {
+			continue
+		}
+		k := getDupeKey(area.GetName(), m)
+		if seen[k] {
+			ret = append(ret, i)
+			continue
+		}
+		seen[k] = true
+		if Dupes != nil {
+			if err = Dupes.Add(area.GetName(), m); err != nil {
+				return ret, err
+			}
+		}
+	}
+	return ret, nil
+}
@@ -0,0 +1,78 @@
+package msgapi
+
+import (
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDupes(t *testing.T) {
+	Area := &MSG{AreaPath: "../../testdata/dupetest/area", AreaName: "test", AreaType: EchoAreaTypeEcho}
+	Areas = Areas[:0]
+	Areas = append(Areas, Area)
+	g := Goblin(t)
+	g.Describe("Check dupe database", func() {
+		m := &Message{
+			AreaID:      0,
+			From:        "SysOp",
+			To:          "All",
+			Subject:     "Test",
+			FromAddr:    types.AddrFromNum(2, 5020, 9696, 1),
+			ToAddr:      &types.FidoAddr{},
+			DateWritten: time.Now(),
+			DateArrived: time.Now(),
+			Body:        "Test\x0d * Origin: test (2:5020/9696.1)\x0d",
+			Kludges:     map[string]string{"MSGID:": "2:5020/9696.1 00000001"},
+		}
+		g.It("scan area", func() {
+			g.Assert(Area.SaveMsg(m)).Equal(nil)
+			g.Assert(Area.SaveMsg(m)).Equal(nil)
+			dupes, err := ScanDupes(Area)
+			g.Assert(err).Equal(nil)
+			g.Assert(dupes).Equal([]uint32{2})
+		})
+		g.It("reject dupe on save", func() {
+			var err error
+			Dupes, err = OpenDupeDB("../../testdata/dupetest/dupes", 0)
+			g.Assert(err).Equal(nil)
+			g.Assert(Area.SaveMsg(m)).Equal(nil)
+			g.Assert(Area.SaveMsg(m)).Equal(ErrDupe)
+			g.Assert(Dupes.Check("other", m)).IsFalse()
+		})
+		g.It("reject dupe with cyrillic header", func() {
+			Area.SetChrs("CP866 2")
+			defer Area.SetChrs("")
+			cm := func() *Message {
+				c := *m
+				c.Subject = "Привет"
+				c.To = "Всем"
+				c.Kludges = map[string]string{"MSGID:": "2:5020/9696.1 00000002"}
+				return &c
+			}
+			g.Assert(Area.SaveMsg(cm())).Equal(nil)
+			g.Assert(Area.SaveMsg(cm())).Equal(ErrDupe)
+		})
+		g.It("failed save not recorded", func() {
+			ioutil.WriteFile("../../testdata/dupetest/file", nil, 0644)
+			broken := &MSG{AreaPath: "../../testdata/dupetest/file/area", AreaName: "broken", AreaType: EchoAreaTypeEcho}
+			g.Assert(broken.SaveMsg(m) == nil).IsFalse()
+			g.Assert(Dupes.Check("broken", m)).IsFalse()
+		})
+		g.It("dupe area keeps copies", func() {
+			dupe := &MSG{AreaPath: "../../testdata/dupetest/dupe", AreaName: "dupe", AreaType: EchoAreaTypeDupe}
+			g.Assert(dupe.SaveMsg(m)).Equal(nil)
+			g.Assert(dupe.SaveMsg(m)).Equal(nil)
+			g.Assert(dupe.GetCount()).Equal(uint32(2))
+		})
+		g.It("reopen database", func() {
+			d, err := OpenDupeDB("../../testdata/dupetest/dupes", time.Hour)
+			g.Assert(err).Equal(nil)
+			g.Assert(d.Check("test", m)).IsTrue()
+		})
+	})
+	Dupes = nil
+	os.RemoveAll("../../testdata/dupetest")
+}
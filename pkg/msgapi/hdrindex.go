@@ -0,0 +1,296 @@
+package msgapi
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IndexPath directory of cached header indexes, empty if disabled
+var IndexPath string
+
+type fileStamp struct {
+	Size    int64
+	ModTime int64
+}
+
+// hdrIndexVersion is stored first, indexes of other versions are rebuilt
+const hdrIndexVersion = 2
+
+// hdrIndex is stored as separate gob values, so count is read without items
+type hdrIndex struct {
+	Stamps []fileStamp
+	Count  uint32
+	Items  []MessageListItem
+}
+
+// baseFiles return files of message base, any change invalidates index
+func baseFiles(area AreaPrimitive) []string {
+	switch a := area.(type) {
+	case *JAM:
+		return []string{a.AreaPath + ".jhr", a.AreaPath + ".jdx"}
+	case *Squish:
+		return []string{a.AreaPath + ".sqd", a.AreaPath + ".sqi"}
+	case *SMB:
+		return []string{a.AreaPath + ".shd", a.AreaPath + ".sid"}
+	case *Hudson:
+		return []string{a.fileName("MSGHDR.BBS"), a.fileName("MSGIDX.BBS")}
+	case *MSG:
+		return []string{a.AreaPath}
+	}
+	return nil
+}
+
+// lastreadFile return lastread file of area
+func lastreadFile(area AreaPrimitive) string {
+	switch a := area.(type) {
+	case *JAM:
+		return a.AreaPath + ".jlr"
+	case *Squish:
+		return a.AreaPath + ".sql"
+	case *SMB:
+		return a.AreaPath + ".glr"
+	case *Hudson:
+		return a.fileName("LASTREAD.BBS")
+	case *MSG:
+		return filepath.Join(a.AreaPath, "lastread")
+	}
+	return ""
+}
+
+// lastCache lastread position valid while base and lastread file are unchanged
+type lastCache struct {
+	Stamps []fileStamp
+	Last   uint32
+}
+
+func indexFile(area AreaPrimitive) string {
+	name := strings.ToLower(string(area.GetMsgType()) + "\x00" + area.GetName())
+	return filepath.Join(IndexPath, fmt.Sprintf("%08x.idx", crc32.ChecksumIEEE([]byte(name))))
+}
+
+func stampFiles(files []string) ([]fileStamp, error) {
+	var stamps []fileStamp
+	for _, fn := range files {
+		fi, err := os.Stat(fn)
+		if err != nil {
+			return nil, err
+		}
+		stamps = append(stamps, fileStamp{fi.Size(), fi.ModTime().UnixNano()})
+	}
+	return stamps, nil
+}
+
+// areaStamps stamp base files of area, directory mtime of MSG area misses
+// messages rewritten in place, so newest *.msg and total size are stamped too
+func areaStamps(area AreaPrimitive) ([]fileStamp, error) {
+	stamps, err := stampFiles(baseFiles(area))
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := area.(*MSG); ok {
+		dir, err := ioutil.ReadDir(m.AreaPath)
+		if err != nil {
+			return nil, err
+		}
+		var newest fileStamp
+		for _, fi := range dir {
+			if fi.IsDir() || !strings.EqualFold(filepath.Ext(fi.Name()), ".msg") {
+				continue
+			}
+			newest.Size += fi.Size()
+			if fi.ModTime().UnixNano() > newest.ModTime {
+				newest.ModTime = fi.ModTime().UnixNano()
+			}
+		}
+		stamps = append(stamps, newest)
+	}
+	return stamps, nil
+}
+
+func sameStamps(a, b []fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func readIndex(fn string, items bool) (*hdrIndex, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	idx := &hdrIndex{}
+	dec := gob.NewDecoder(f)
+	var version int
+	if err = dec.Decode(&version); err != nil {
+		return nil, err
+	}
+	if version != hdrIndexVersion {
+		return nil, errors.New(fn + ": index version mismatch")
+	}
+	if err = dec.Decode(&idx.Stamps); err != nil {
+		return nil, err
+	}
+	if err = dec.Decode(&idx.Count); err != nil {
+		return nil, err
+	}
+	if items {
+		if err = dec.Decode(&idx.Items); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+func writeIndex(fn string, idx *hdrIndex) error {
+	return writeGob(fn, hdrIndexVersion, idx.Stamps, idx.Count, idx.Items)
+}
+
+// writeGob write values to fn through temporary file
+func writeGob(fn string, values ...interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(fn + ".tmp")
+	if err != nil {
+		return err
+	}
+	enc := gob.NewEncoder(f)
+	for _, v := range values {
+		if err = enc.Encode(v); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(fn + ".tmp")
+		return err
+	}
+	f.Close()
+	return os.Rename(fn+".tmp", fn)
+}
+
+// messageListItem header of message at position n
+func messageListItem(n uint32, m *Message) MessageListItem {
+	reply := m.Kludges["REPLY:"]
+	if reply == "" {
+		reply = m.Kludges["REPLYID:"]
+	}
+	return MessageListItem{
+		MsgNum:      n,
+		From:        m.From,
+		To:          m.To,
+		Subject:     m.Subject,
+		DateWritten: m.DateWritten,
+		MsgID:       m.Kludges["MSGID:"],
+		Reply:       reply,
+	}
+}
+
+func listItem(area AreaPrimitive, n uint32) (MessageListItem, bool) {
+	m, err := area.GetMsg(n)
+	if err != nil || m == nil {
+		return MessageListItem{}, false
+	}
+	return messageListItem(n, m), true
+}
+
+// readMessageList read headers of area from index, messages appended since
+// index was written are read from base, anything else rebuilds it
+func readMessageList(area AreaPrimitive) []MessageListItem {
+	var items []MessageListItem
+	count := area.GetCount()
+	files := baseFiles(area)
+	if IndexPath == "" || len(files) == 0 {
+		return appendMessageList(area, items, 1, count)
+	}
+	fn := indexFile(area)
+	stamps, err := areaStamps(area)
+	if err != nil {
+		return appendMessageList(area, items, 1, count)
+	}
+	idx, err := readIndex(fn, true)
+	if err == nil && sameStamps(idx.Stamps, stamps) && idx.Count == count {
+		return idx.Items
+	}
+	from := uint32(1)
+	if err == nil && idx.Count > 0 && idx.Count < count && len(idx.Items) > 0 {
+		last := idx.Items[len(idx.Items)-1]
+		if cur, ok := listItem(area, last.MsgNum); ok && cur.From == last.From && cur.Subject == last.Subject && cur.DateWritten.Equal(last.DateWritten) {
+			items = idx.Items
+			from = idx.Count + 1
+		}
+	}
+	items = appendMessageList(area, items, from, count)
+	writeIndex(fn, &hdrIndex{Stamps: stamps, Count: count, Items: items})
+	return items
+}
+
+func appendMessageList(area AreaPrimitive, items []MessageListItem, from, to uint32) []MessageListItem {
+	for i := from; i <= to; i++ {
+		if mi, ok := listItem(area, i); ok {
+			items = append(items, mi)
+		}
+	}
+	return items
+}
+
+// CachedCount return message count of area from header index if it is up to date
+func CachedCount(area AreaPrimitive) uint32 {
+	files := baseFiles(area)
+	if IndexPath == "" || len(files) == 0 {
+		return area.GetCount()
+	}
+	stamps, err := areaStamps(area)
+	if err != nil {
+		return area.GetCount()
+	}
+	idx, err := readIndex(indexFile(area), false)
+	if err != nil || !sameStamps(idx.Stamps, stamps) {
+		return area.GetCount()
+	}
+	return idx.Count
+}
+
+// CachedLast return lastread position of area from cache if base and lastread
+// file are unchanged, otherwise it is read from base and cached
+func CachedLast(area AreaPrimitive) uint32 {
+	files := baseFiles(area)
+	if IndexPath == "" || len(files) == 0 {
+		return area.GetLast()
+	}
+	stamps, err := areaStamps(area)
+	if err != nil {
+		return area.GetLast()
+	}
+	// missing lastread file is stamped as zero
+	var lr fileStamp
+	if fi, err := os.Stat(lastreadFile(area)); err == nil {
+		lr = fileStamp{fi.Size(), fi.ModTime().UnixNano()}
+	}
+	stamps = append(stamps, lr)
+	fn := strings.TrimSuffix(indexFile(area), ".idx") + ".lr"
+	var c lastCache
+	if f, err := os.Open(fn); err == nil {
+		err = gob.NewDecoder(f).Decode(&c)
+		f.Close()
+		if err == nil && sameStamps(c.Stamps, stamps) {
+			return c.Last
+		}
+	}
+	c = lastCache{Stamps: stamps, Last: area.GetLast()}
+	writeGob(fn, &c)
+	return c.Last
+}
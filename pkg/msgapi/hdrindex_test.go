@@ -0,0 +1,88 @@
+package msgapi
+
+import (
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHdrIndex(t *testing.T) {
+	IndexPath = "../../testdata/idxtest/index"
+	Area := &MSG{AreaPath: "../../testdata/idxtest/msg", AreaName: "test", AreaType: EchoAreaTypeEcho}
+	Areas = Areas[:0]
+	Areas = append(Areas, Area)
+	save := func(a AreaPrimitive, subj string) {
+		a.SaveMsg(&Message{
+			From:        "SysOp",
+			To:          "All",
+			Subject:     subj,
+			FromAddr:    types.AddrFromNum(2, 5020, 9696, 1),
+			ToAddr:      &types.FidoAddr{},
+			DateWritten: time.Now(),
+			DateArrived: time.Now(),
+			Body:        "Test\x0d * Origin: test (2:5020/9696.1)\x0d",
+			Kludges:     make(map[string]string),
+		})
+	}
+	for _, subj := range []string{"one", "two", "three"} {
+		save(Area, subj)
+	}
+	g := Goblin(t)
+	g.Describe("Check header index", func() {
+		g.It("build index", func() {
+			g.Assert(len(*Area.GetMessages())).Equal(3)
+			_, err := os.Stat(indexFile(Area))
+			g.Assert(err).Equal(nil)
+			g.Assert(CachedCount(Area)).Equal(uint32(3))
+		})
+		g.It("incremental update", func() {
+			save(Area, "four")
+			fresh := &MSG{AreaPath: "../../testdata/idxtest/msg", AreaName: "test", AreaType: EchoAreaTypeEcho}
+			msgs := *fresh.GetMessages()
+			g.Assert(len(msgs)).Equal(4)
+			g.Assert(msgs[0].Subject).Equal("one")
+			g.Assert(msgs[3].Subject).Equal("four")
+			g.Assert(msgs[3].MsgNum).Equal(uint32(4))
+			g.Assert(CachedCount(fresh)).Equal(uint32(4))
+		})
+		g.It("rebuild on change", func() {
+			idx, _ := readIndex(indexFile(Area), true)
+			idx.Items[2].Subject = "changed"
+			writeIndex(indexFile(Area), idx)
+			os.Chtimes(Area.AreaPath, time.Now(), time.Now().Add(time.Hour))
+			fresh := &MSG{AreaPath: "../../testdata/idxtest/msg", AreaName: "test", AreaType: EchoAreaTypeEcho}
+			g.Assert((*fresh.GetMessages())[2].Subject).Equal("three")
+		})
+		g.It("rebuild on message rewritten in place", func() {
+			idx, _ := readIndex(indexFile(Area), true)
+			idx.Items[1].Subject = "changed"
+			writeIndex(indexFile(Area), idx)
+			dir, _ := os.Stat(Area.AreaPath)
+			os.Chtimes(filepath.Join(Area.AreaPath, "2.msg"), time.Now(), time.Now().Add(2*time.Hour))
+			os.Chtimes(Area.AreaPath, dir.ModTime(), dir.ModTime())
+			fresh := &MSG{AreaPath: "../../testdata/idxtest/msg", AreaName: "test", AreaType: EchoAreaTypeEcho}
+			g.Assert((*fresh.GetMessages())[1].Subject).Equal("two")
+		})
+		g.It("cached lastread", func() {
+			Area.SetLast(2)
+			g.Assert(CachedLast(Area)).Equal(uint32(2))
+			fresh := &MSG{AreaPath: "../../testdata/idxtest/msg", AreaName: "test", AreaType: EchoAreaTypeEcho}
+			g.Assert(CachedLast(fresh)).Equal(uint32(2))
+			g.Assert(len(fresh.messageNums)).Equal(0)
+			Area.SetLast(3)
+			g.Assert(CachedLast(fresh)).Equal(uint32(3))
+		})
+		g.It("detached area", func() {
+			d := Detached(Area).(*MSG)
+			g.Assert(d == Area).IsFalse()
+			g.Assert(len(d.messageNums)).Equal(0)
+			g.Assert(CachedCount(d)).Equal(Area.GetCount())
+			g.Assert(CachedLast(d)).Equal(Area.GetLast())
+		})
+	})
+	IndexPath = ""
+	os.RemoveAll("../../testdata/idxtest")
+}
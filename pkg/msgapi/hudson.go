@@ -0,0 +1,611 @@
+package msgapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/askovpen/gossiped/pkg/types"
+	"github.com/askovpen/gossiped/pkg/utils"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Hudson struct
+type Hudson struct {
+	AreaPath       string
+	AreaName       string
+	AreaType       EchoAreaType
+	Chrs           string
+	Board          uint8
+	UserRecord     uint16
+	indexStructure []hudsonS
+	messages       []MessageListItem
+}
+
+type hudsonS struct {
+	MessageNum uint16
+	Record     uint32
+}
+
+type hudsonInfo struct {
+	LowMsg, HighMsg, TotalActive uint16
+	ActiveMsgs                   [200]uint16
+}
+
+type hudsonIdx struct {
+	MsgNum uint16
+	Board  uint8
+}
+
+type hudsonHdr struct {
+	MsgNum, PrevReply, NextReply, TimesRead uint16
+	StartBlock, NumBlocks                   uint16
+	DestNet, DestNode, OrigNet, OrigNode    uint16
+	DestZone, OrigZone                      uint8
+	Cost                                    uint16
+	MsgAttr, NetAttr, Board                 uint8
+	PostTime                                [6]byte
+	PostDate                                [9]byte
+	WhoTo, WhoFrom                          [36]byte
+	Subject                                 [73]byte
+}
+
+// HudsonMsgAttrs Hudson message attributes
+type HudsonMsgAttrs uint8
+
+// message attributes
+const (
+	HudsonDELETED  HudsonMsgAttrs = 0x01
+	HudsonUNMOVNET HudsonMsgAttrs = 0x02
+	HudsonNETMAIL  HudsonMsgAttrs = 0x04
+	HudsonPRIVATE  HudsonMsgAttrs = 0x08
+	HudsonRECEIVED HudsonMsgAttrs = 0x10
+	HudsonUNMOVECH HudsonMsgAttrs = 0x20
+	HudsonLOCAL    HudsonMsgAttrs = 0x40
+)
+
+// HudsonNetAttrs Hudson netmail attributes
+type HudsonNetAttrs uint8
+
+// netmail attributes
+const (
+	HudsonKILL  HudsonNetAttrs = 0x01
+	HudsonSENT  HudsonNetAttrs = 0x02
+	HudsonFILE  HudsonNetAttrs = 0x04
+	HudsonCRASH HudsonNetAttrs = 0x08
+	HudsonRRQ   HudsonNetAttrs = 0x10
+	HudsonARQ   HudsonNetAttrs = 0x20
+	HudsonRRC   HudsonNetAttrs = 0x40
+)
+
+const (
+	hudsonHdrLen = 187
+	hudsonBlock  = 256
+	hudsonLrLen  = 400
+	// multinode writers lock byte past end of MSGINFO.BBS
+	hudsonLockOffset = 407
+)
+
+// hudsonMsgAttrs and hudsonNetAttrs attribute names by bit
+var (
+	hudsonMsgAttrs = []string{
+		"[red]Del[silver]", "", "", "Pvt",
+		"Rcv", "", "Loc", "",
+	}
+	hudsonNetAttrs = []string{
+		"K/s", "Snt", "Att", "Cra",
+		"Rrq", "Arq", "Cpt", "",
+	}
+)
+
+func (h *Hudson) getAttrs(ma uint8, na uint8) (attrs []string) {
+	for i := 0; ma > 0; i++ {
+		if ma&1 > 0 && hudsonMsgAttrs[i] != "" {
+			attrs = append(attrs, hudsonMsgAttrs[i])
+		}
+		ma >>= 1
+	}
+	for i := 0; na > 0; i++ {
+		if na&1 > 0 && hudsonNetAttrs[i] != "" {
+			attrs = append(attrs, hudsonNetAttrs[i])
+		}
+		na >>= 1
+	}
+	return
+}
+
+// getHudsonAttrNum message and netmail attribute bits, deleted flag is not set
+func getHudsonAttrNum(attrs []string) (ma uint8, na uint8) {
+	for _, attr := range attrs {
+		for i, d := range hudsonMsgAttrs[1:] {
+			if d != "" && d == attr {
+				ma |= 1 << uint(i+1)
+			}
+		}
+		for i, d := range hudsonNetAttrs {
+			if d != "" && d == attr {
+				na |= 1 << uint(i)
+			}
+		}
+	}
+	return
+}
+
+func fromPascal(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	l := int(b[0])
+	if l > len(b)-1 {
+		l = len(b) - 1
+	}
+	return string(b[1 : l+1])
+}
+
+func toPascal(dst []byte, s string) {
+	if len(s) > len(dst)-1 {
+		s = s[:len(dst)-1]
+	}
+	dst[0] = byte(len(s))
+	copy(dst[1:], s)
+}
+
+func (h *Hudson) fileName(name string) string {
+	return filepath.Join(h.AreaPath, name)
+}
+
+func (h *Hudson) getOffsetByNum(num uint16) uint32 {
+	for i, is := range h.indexStructure {
+		if is.MessageNum == num {
+			return uint32(i) + 1
+		}
+	}
+	return 0
+}
+
+func (h *Hudson) readHeader(f *os.File, record uint32) (hudsonHdr, error) {
+	var hdr hudsonHdr
+	_, err := f.Seek(int64(record)*hudsonHdrLen, 0)
+	if err != nil {
+		return hdr, err
+	}
+	header := make([]byte, hudsonHdrLen)
+	_, err = f.Read(header)
+	if err != nil {
+		return hdr, err
+	}
+	err = utils.ReadStructFromBuffer(bytes.NewBuffer(header), &hdr)
+	return hdr, err
+}
+
+func parseHudsonDate(date string, tm string) time.Time {
+	ret, _ := time.ParseInLocation("01-02-06 15:04", date+" "+tm, time.Local)
+	return ret
+}
+
+// GetMsg return msg
+func (h *Hudson) GetMsg(position uint32) (*Message, error) {
+	if len(h.indexStructure) == 0 {
+		return nil, nil
+	}
+	if position == 0 {
+		position = 1
+	}
+	f, err := os.Open(h.fileName("MSGHDR.BBS"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hdr, err := h.readHeader(f, h.indexStructure[position-1].Record)
+	if err != nil {
+		return nil, err
+	}
+	if hdr.MsgNum != h.indexStructure[position-1].MessageNum {
+		return nil, errors.New("wrong message number in header")
+	}
+	rm := &Message{Area: h.AreaName,
+		MsgNum:      position,
+		MaxNum:      uint32(len(h.indexStructure)),
+		From:        fromPascal(hdr.WhoFrom[:]),
+		To:          fromPascal(hdr.WhoTo[:]),
+		Subject:     fromPascal(hdr.Subject[:]),
+		DateWritten: parseHudsonDate(fromPascal(hdr.PostDate[:]), fromPascal(hdr.PostTime[:])),
+		Attrs:       h.getAttrs(hdr.MsgAttr, hdr.NetAttr),
+	}
+	rm.DateArrived = rm.DateWritten
+	if h.AreaType != EchoAreaTypeLocal && h.AreaType != EchoAreaTypeEcho {
+		rm.FromAddr = types.AddrFromNum(uint16(hdr.OrigZone), hdr.OrigNet, hdr.OrigNode, 0)
+		rm.ToAddr = types.AddrFromNum(uint16(hdr.DestZone), hdr.DestNet, hdr.DestNode, 0)
+	}
+	if hdr.PrevReply > 0 {
+		rm.ReplyTo = h.getOffsetByNum(hdr.PrevReply)
+	}
+	if hdr.NextReply > 0 {
+		rm.Replies = append(rm.Replies, h.getOffsetByNum(hdr.NextReply))
+	}
+	fTxt, err := os.Open(h.fileName("MSGTXT.BBS"))
+	if err != nil {
+		return nil, err
+	}
+	defer fTxt.Close()
+	_, err = fTxt.Seek(int64(hdr.StartBlock)*hudsonBlock, 0)
+	if err != nil {
+		return nil, err
+	}
+	txt := make([]byte, int(hdr.NumBlocks)*hudsonBlock)
+	fTxt.Read(txt)
+	var body strings.Builder
+	for i := 0; i < int(hdr.NumBlocks); i++ {
+		body.WriteString(fromPascal(txt[i*hudsonBlock : (i+1)*hudsonBlock]))
+	}
+	rm.Body = strings.Replace(body.String(), "\x0a", "", -1)
+	err = rm.ParseRaw()
+	if err != nil {
+		return nil, err
+	}
+	// echomail without origin line, fall back to header address
+	if rm.FromAddr.GetZone() == 0 && hdr.OrigZone > 0 {
+		rm.FromAddr = types.AddrFromNum(uint16(hdr.OrigZone), hdr.OrigNet, hdr.OrigNode, 0)
+		rm.Corrupted = false
+	}
+	return rm, nil
+}
+
+func (h *Hudson) readIdx() {
+	if len(h.indexStructure) > 0 {
+		return
+	}
+	file, err := os.Open(h.fileName("MSGIDX.BBS"))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	part := make([]byte, 3*4096)
+	i := uint32(0)
+	for {
+		count, err := reader.Read(part)
+		if err != nil {
+			break
+		}
+		partb := bytes.NewBuffer(part[:count])
+		for {
+			var idx hudsonIdx
+			if err = utils.ReadStructFromBuffer(partb, &idx); err != nil {
+				break
+			}
+			if idx.Board == h.Board && idx.MsgNum != 0xffff && idx.MsgNum != 0 {
+				h.indexStructure = append(h.indexStructure, hudsonS{idx.MsgNum, i})
+			}
+			i++
+		}
+	}
+}
+
+func (h *Hudson) readInfo() (info hudsonInfo, err error) {
+	b, err := ioutil.ReadFile(h.fileName("MSGINFO.BBS"))
+	if err != nil {
+		return info, err
+	}
+	err = utils.ReadStructFromBuffer(bytes.NewBuffer(b), &info)
+	return info, err
+}
+
+func (h *Hudson) writeInfo(info hudsonInfo) error {
+	buf := new(bytes.Buffer)
+	err := utils.WriteStructToBuffer(buf, &info)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(h.fileName("MSGINFO.BBS"), buf.Bytes(), 0644)
+}
+
+func (h *Hudson) readLastRead() []uint16 {
+	lr := make([]uint16, 200)
+	b, err := ioutil.ReadFile(h.fileName("LASTREAD.BBS"))
+	if err != nil {
+		return lr
+	}
+	offset := int(h.UserRecord) * hudsonLrLen
+	if len(b) < offset+hudsonLrLen {
+		return lr
+	}
+	binary.Read(bytes.NewReader(b[offset:offset+hudsonLrLen]), binary.LittleEndian, lr)
+	return lr
+}
+
+// GetLast return last message
+func (h *Hudson) GetLast() uint32 {
+	h.readIdx()
+	if len(h.indexStructure) == 0 || h.Board == 0 {
+		return 0
+	}
+	msgNum := h.readLastRead()[h.Board-1]
+	for i, is := range h.indexStructure {
+		if is.MessageNum == msgNum {
+			return uint32(i + 1)
+		}
+	}
+	if msgNum != 0 {
+		for i := len(h.indexStructure) - 1; i >= 0; i-- {
+			if h.indexStructure[i].MessageNum < msgNum {
+				return uint32(i + 1)
+			}
+		}
+	}
+	return 0
+}
+
+// SetLast set last message
+func (h *Hudson) SetLast(l uint32) {
+	if l == 0 {
+		l = 1
+	}
+	if h.Board == 0 || int(l) > len(h.indexStructure) {
+		return
+	}
+	b, _ := ioutil.ReadFile(h.fileName("LASTREAD.BBS"))
+	offset := int(h.UserRecord) * hudsonLrLen
+	if len(b) < offset+hudsonLrLen {
+		b = append(b, make([]byte, offset+hudsonLrLen-len(b))...)
+	}
+	binary.LittleEndian.PutUint16(b[offset+2*(int(h.Board)-1):], h.indexStructure[l-1].MessageNum)
+	err := ioutil.WriteFile(h.fileName("LASTREAD.BBS"), b, 0644)
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// GetCount return count messages
+func (h *Hudson) GetCount() uint32 {
+	h.readIdx()
+	return uint32(len(h.indexStructure))
+}
+
+// GetMsgType return msg base type
+func (h *Hudson) GetMsgType() EchoAreaMsgType {
+	return EchoAreaMsgTypeHudson
+}
+
+// GetType return area type
+func (h *Hudson) GetType() EchoAreaType {
+	return h.AreaType
+}
+
+// Init init
+func (h *Hudson) Init() {
+}
+
+// GetName return area name
+func (h *Hudson) GetName() string {
+	return h.AreaName
+}
+
+// SetChrs set charset
+func (h *Hudson) SetChrs(c string) {
+	h.Chrs = c
+}
+
+// GetChrs get charset
+func (h *Hudson) GetChrs() string {
+	return h.Chrs
+}
+
+func appendFile(fn string, data []byte) (int64, error) {
+	f, err := os.OpenFile(fn, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	offset, err := f.Seek(0, 2)
+	if err != nil {
+		return 0, err
+	}
+	_, err = f.Write(data)
+	return offset, err
+}
+
+// SaveMsg save message
+func (h *Hudson) SaveMsg(tm *Message) (err error) {
+	if h.Board == 0 || h.Board > 200 {
+		return errors.New("wrong Hudson board number")
+	}
+	key, err := checkDupe(h.AreaName, h.AreaType, tm)
+	if err != nil {
+		return err
+	}
+	defer addDupe(h.AreaType, key, &err)
+	if _, err := os.Stat(h.AreaPath); os.IsNotExist(err) {
+		err = os.MkdirAll(h.AreaPath, 0755)
+		if err != nil {
+			return err
+		}
+	}
+	lock, err := lockFile(h.fileName("MSGINFO.BBS"), hudsonLockOffset)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	h.readIdx()
+	info, _ := h.readInfo()
+	tm.Encode()
+	body := ""
+	for kl, v := range tm.Kludges {
+		body += "\x01" + kl + " " + v + "\x0d"
+	}
+	body += tm.Body
+	txt := new(bytes.Buffer)
+	for len(body) > 0 {
+		block := make([]byte, hudsonBlock)
+		toPascal(block, body)
+		if len(body) > hudsonBlock-1 {
+			body = body[hudsonBlock-1:]
+		} else {
+			body = ""
+		}
+		txt.Write(block)
+	}
+	offset, err := appendFile(h.fileName("MSGTXT.BBS"), txt.Bytes())
+	if err != nil {
+		return err
+	}
+	hdr := hudsonHdr{
+		MsgNum:     info.HighMsg + 1,
+		StartBlock: uint16(offset / hudsonBlock),
+		NumBlocks:  uint16(txt.Len() / hudsonBlock),
+		OrigZone:   uint8(tm.FromAddr.GetZone()),
+		OrigNet:    tm.FromAddr.GetNet(),
+		OrigNode:   tm.FromAddr.GetNode(),
+		Board:      h.Board,
+	}
+	ma, na := getHudsonAttrNum(tm.Attrs)
+	hdr.MsgAttr = ma
+	if h.AreaType == EchoAreaTypeNetmail {
+		hdr.MsgAttr |= uint8(HudsonNETMAIL | HudsonUNMOVNET)
+		hdr.NetAttr = na
+		hdr.DestZone = uint8(tm.ToAddr.GetZone())
+		hdr.DestNet = tm.ToAddr.GetNet()
+		hdr.DestNode = tm.ToAddr.GetNode()
+	} else if h.AreaType == EchoAreaTypeEcho {
+		hdr.MsgAttr |= uint8(HudsonUNMOVECH)
+	}
+	toPascal(hdr.PostTime[:], tm.DateWritten.Format("15:04"))
+	toPascal(hdr.PostDate[:], tm.DateWritten.Format("01-02-06"))
+	toPascal(hdr.WhoTo[:], tm.To)
+	toPascal(hdr.WhoFrom[:], tm.From)
+	toPascal(hdr.Subject[:], tm.Subject)
+	buf := new(bytes.Buffer)
+	err = utils.WriteStructToBuffer(buf, &hdr)
+	if err != nil {
+		return err
+	}
+	offset, err = appendFile(h.fileName("MSGHDR.BBS"), buf.Bytes())
+	if err != nil {
+		return err
+	}
+	buf.Reset()
+	idx := hudsonIdx{MsgNum: hdr.MsgNum, Board: h.Board}
+	err = utils.WriteStructToBuffer(buf, &idx)
+	if err != nil {
+		return err
+	}
+	_, err = appendFile(h.fileName("MSGIDX.BBS"), buf.Bytes())
+	if err != nil {
+		return err
+	}
+	toIdx := make([]byte, 36)
+	toPascal(toIdx, tm.To)
+	_, err = appendFile(h.fileName("MSGTOIDX.BBS"), toIdx)
+	if err != nil {
+		return err
+	}
+	if info.LowMsg == 0 {
+		info.LowMsg = hdr.MsgNum
+	}
+	info.HighMsg = hdr.MsgNum
+	info.TotalActive++
+	info.ActiveMsgs[h.Board-1]++
+	err = h.writeInfo(info)
+	if err != nil {
+		return err
+	}
+	h.indexStructure = append(h.indexStructure, hudsonS{hdr.MsgNum, uint32(offset / hudsonHdrLen)})
+	return nil
+}
+
+// GetMessages get headers
+func (h *Hudson) GetMessages() *[]MessageListItem {
+	if h.GetCount() == 0 || uint32(len(h.messages)) == h.GetCount() {
+		return &h.messages
+	}
+	h.messages = readMessageList(h)
+	return &h.messages
+}
+
+// DelMsg remove msg
+func (h *Hudson) DelMsg(l uint32) error {
+	if len(h.indexStructure) == 0 {
+		return errors.New("empty Area")
+	}
+	if l == 0 {
+		l = 1
+	}
+	lock, err := lockFile(h.fileName("MSGINFO.BBS"), hudsonLockOffset)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	record := h.indexStructure[l-1].Record
+	f, err := os.OpenFile(h.fileName("MSGHDR.BBS"), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	hdr, err := h.readHeader(f, record)
+	if err != nil {
+		return err
+	}
+	hdr.MsgAttr |= uint8(HudsonDELETED)
+	buf := new(bytes.Buffer)
+	err = utils.WriteStructToBuffer(buf, &hdr)
+	if err != nil {
+		return err
+	}
+	f.Seek(int64(record)*hudsonHdrLen, 0)
+	_, err = f.Write(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	f.Close()
+	f, err = os.OpenFile(h.fileName("MSGIDX.BBS"), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	buf.Reset()
+	idx := hudsonIdx{MsgNum: 0xffff, Board: h.Board}
+	utils.WriteStructToBuffer(buf, &idx)
+	f.Seek(int64(record)*3, 0)
+	_, err = f.Write(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	f.Close()
+	f, err = os.OpenFile(h.fileName("MSGTOIDX.BBS"), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	toIdx := make([]byte, 36)
+	toPascal(toIdx, "* Deleted *")
+	f.Seek(int64(record)*36, 0)
+	_, err = f.Write(toIdx)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	info, err := h.readInfo()
+	if err != nil {
+		return err
+	}
+	if info.TotalActive > 0 {
+		info.TotalActive--
+	}
+	if info.ActiveMsgs[h.Board-1] > 0 {
+		info.ActiveMsgs[h.Board-1]--
+	}
+	err = h.writeInfo(info)
+	if err != nil {
+		return err
+	}
+	if len(h.messages) == len(h.indexStructure) {
+		h.messages = append(h.messages[:l-1], h.messages[l:]...)
+	}
+	h.indexStructure = append(h.indexStructure[:l-1], h.indexStructure[l:]...)
+	return nil
+}
@@ -0,0 +1,78 @@
+package msgapi
+
+import (
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHudson(t *testing.T) {
+	Area := &Hudson{
+		AreaPath: "../../testdata/hudsontest",
+		AreaName: "test",
+		AreaType: EchoAreaTypeEcho,
+		Board:    3,
+	}
+	Other := &Hudson{
+		AreaPath: "../../testdata/hudsontest",
+		AreaName: "other",
+		AreaType: EchoAreaTypeEcho,
+		Board:    4,
+	}
+	Areas = Areas[:0]
+	Areas = append(Areas, Area, Other)
+	g := Goblin(t)
+	g.Describe("Check Hudson read/write", func() {
+		m := &Message{
+			AreaID:      0,
+			From:        "SysOp",
+			To:          "SysOp",
+			Subject:     "Test",
+			FromAddr:    types.AddrFromNum(2, 5020, 9696, 1),
+			ToAddr:      types.AddrFromNum(2, 5020, 9696, 2),
+			DateWritten: time.Now(),
+			DateArrived: time.Now(),
+			Body:        "Test\nBody\n * Origin: test (2:5020/9696.1)",
+			Kludges:     make(map[string]string),
+		}
+		m.MakeBody()
+		g.It("create msg", func() {
+			g.Assert(len(*Area.GetMessages())).Equal(0)
+			g.Assert(Area.SaveMsg(m)).Equal(nil)
+		})
+		g.It("add msg to other board", func() {
+			g.Assert(Other.SaveMsg(m)).Equal(nil)
+			g.Assert(Other.GetCount()).Equal(uint32(1))
+		})
+		g.It("add msg", func() {
+			g.Assert(Area.SaveMsg(m)).Equal(nil)
+		})
+		g.It("check num msgs", func() {
+			g.Assert(Area.GetCount()).Equal(uint32(2))
+			fresh := &Hudson{AreaPath: "../../testdata/hudsontest", AreaName: "test", Board: 3}
+			g.Assert(fresh.GetCount()).Equal(uint32(2))
+		})
+		g.It("read msg", func() {
+			nm, err := Area.GetMsg(2)
+			g.Assert(err).Equal(nil)
+			g.Assert(nm.FromAddr).Equal(types.AddrFromNum(2, 5020, 9696, 1))
+			g.Assert(nm.Subject).Equal("Test")
+			g.Assert(nm.Kludges["MSGID:"]).Equal(m.Kludges["MSGID:"])
+		})
+		g.It("get/set last", func() {
+			Area.SetLast(2)
+			g.Assert(Area.GetLast()).Equal(uint32(2))
+			g.Assert(Other.GetLast()).Equal(uint32(0))
+			g.Assert(len(*Area.GetMessages())).Equal(2)
+		})
+		g.It("del msg", func() {
+			g.Assert(Area.DelMsg(1)).Equal(nil)
+			g.Assert(Area.GetCount()).Equal(uint32(1))
+			fresh := &Hudson{AreaPath: "../../testdata/hudsontest", AreaName: "test", Board: 3}
+			g.Assert(fresh.GetCount()).Equal(uint32(1))
+		})
+	})
+	os.RemoveAll("../../testdata/hudsontest")
+}
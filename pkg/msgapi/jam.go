@@ -24,6 +24,7 @@ type JAM struct {
 	AreaType           EchoAreaType
 	Chrs               string
 	indexStructure     []jamS
+	idxRecords         uint32
 	lastRead           []jamL
 	messages           []MessageListItem
 	headerStructure    jhrS
@@ -60,24 +61,10 @@ type jamL struct {
 }
 
 func (j *JAM) getAttrs(a uint32) (attrs []string) {
-	datr := []string{
-		"Loc", "", "Pvt", "Rcv",
-		"Snt", "", "", "",
-		"", "", "", "",
-		"", "", "", "",
-		"", "", "", "",
-		"", "", "", "",
-		"", "", "", "",
-		"", "", "", "[red]Del[silver]",
-	}
-	i := 0
-	for a > 0 {
-		if a&1 > 0 {
-			if datr[i] != "" {
-				attrs = append(attrs, datr[i])
-			}
+	for i := 0; a > 0; i++ {
+		if a&1 > 0 && jamAttrs[i] != "" {
+			attrs = append(attrs, jamAttrs[i])
 		}
-		i++
 		a >>= 1
 	}
 	return
@@ -237,10 +224,17 @@ func (j *JAM) readJDX() {
 		return
 	}
 	defer fJhr.Close()
+	j.readIndex(fJhr)
+}
+
+// readIndex read base header from fJhr and message index
+func (j *JAM) readIndex(fJhr *os.File) {
+	j.indexStructure = nil
+	j.idxRecords = 0
 	header := make([]byte, 1024)
-	fJhr.Read(header)
+	fJhr.ReadAt(header, 0)
 	headerb := bytes.NewBuffer(header)
-	if err = utils.ReadStructFromBuffer(headerb, &j.headerStructure); err != nil {
+	if err := utils.ReadStructFromBuffer(headerb, &j.headerStructure); err != nil {
 		return
 	}
 
@@ -269,6 +263,8 @@ func (j *JAM) readJDX() {
 			i++
 		}
 	}
+	// gaps left by pack without renumbering are counted too
+	j.idxRecords = i
 	// sort.Slice(j.indexStructure, func(a, b int) bool { return j.indexStructure[a].MessageNum < j.indexStructure[b].MessageNum })
 }
 
@@ -473,12 +469,23 @@ func packJamKludges(tm *Message) []byte {
 }
 
 // SaveMsg save message
-func (j *JAM) SaveMsg(tm *Message) error {
+func (j *JAM) SaveMsg(tm *Message) (err error) {
+	key, err := checkDupe(j.AreaName, j.AreaType, tm)
+	if err != nil {
+		return err
+	}
+	defer addDupe(j.AreaType, key, &err)
 	//	if len(j.indexStructure) == 0 {
 	//		return errors.New("creating JAM area not implemented")
 	//	}
+	lock, err := lockFile(j.AreaPath+".jhr", 0)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	j.readIndex(lock.File)
 	var jhr jhrS
-	if len(j.indexStructure) == 0 {
+	if j.idxRecords == 0 {
 		jhr.Signature = 0x4d414a
 		jhr.PasswordCRC = 0xffffffff
 		jhr.BaseMsgNum = 1
@@ -487,7 +494,15 @@ func (j *JAM) SaveMsg(tm *Message) error {
 		jhr = j.headerStructure
 	}
 
-	jamh := jamH{Signature: 0x4d414a, Revision: 1, Attribute: 0x01000001}
+	jamh := jamH{Signature: 0x4d414a, Revision: 1, Attribute: getJamAttrNum(tm.Attrs)}
+	switch j.AreaType {
+	case EchoAreaTypeNetmail:
+		jamh.Attribute |= jamTypeNet
+	case EchoAreaTypeLocal:
+		jamh.Attribute |= jamTypeLocal
+	default:
+		jamh.Attribute |= jamTypeEcho
+	}
 	tm.Encode()
 	kl := packJamKludges(tm)
 	jamh.SubfieldLen = uint32(len(kl))
@@ -502,7 +517,7 @@ func (j *JAM) SaveMsg(tm *Message) error {
 	jamh.DateReceived = uint32(tm.DateArrived.Unix())
 	jamh.DateProcessed = uint32(tm.DateArrived.Unix())
 	jamh.TxtLen = uint32(len(tm.Body))
-	jamh.MessageNumber = uint32(len(j.indexStructure)) + jhr.BaseMsgNum
+	jamh.MessageNumber = j.idxRecords + jhr.BaseMsgNum
 	jam := jamSH{ToCRC: crc32r(tm.To)}
 	f, err := os.OpenFile(j.AreaPath+".jdt", os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
@@ -513,11 +528,7 @@ func (j *JAM) SaveMsg(tm *Message) error {
 	jamh.Offset = uint32(offset)
 	f.Write([]byte(tm.Body))
 	f.Close()
-	f, err = os.OpenFile(j.AreaPath+".jhr", os.O_RDWR|os.O_CREATE, 0644)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+	f = lock.File
 	jhr.ActiveMsgs++
 	buf := new(bytes.Buffer)
 	err = utils.WriteStructToBuffer(buf, &jhr)
@@ -536,14 +547,13 @@ func (j *JAM) SaveMsg(tm *Message) error {
 	}
 	f.Write(buf.Bytes())
 	f.Write(kl)
-	f.Close()
 	buf.Reset()
 	f, err = os.OpenFile(j.AreaPath+".jdx", os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	if len(j.indexStructure) == 0 {
+	if j.idxRecords == 0 {
 		f.Seek(0, 0)
 	} else {
 		f.Seek(0, 2)
@@ -555,6 +565,7 @@ func (j *JAM) SaveMsg(tm *Message) error {
 	f.Write(buf.Bytes())
 	f.Close()
 	j.indexStructure = append(j.indexStructure, jamS{jamh.MessageNumber, jam})
+	j.idxRecords++
 	return nil
 }
 
@@ -570,22 +581,10 @@ func (j *JAM) GetChrs() string {
 
 // GetMessages get headers
 func (j *JAM) GetMessages() *[]MessageListItem {
-	if len(j.messages) > 0 || len(j.indexStructure) == 0 {
+	if j.GetCount() == 0 || uint32(len(j.messages)) == j.GetCount() {
 		return &j.messages
 	}
-	for i := uint32(0); i < j.GetCount(); i++ {
-		m, err := j.GetMsg(i + 1)
-		if err != nil {
-			continue
-		}
-		j.messages = append(j.messages, MessageListItem{
-			MsgNum:      i + 1,
-			From:        m.From,
-			To:          m.To,
-			Subject:     m.Subject,
-			DateWritten: m.DateWritten,
-		})
-	}
+	j.messages = readMessageList(j)
 	return &j.messages
 }
 
@@ -594,11 +593,12 @@ func (j *JAM) DelMsg(l uint32) error {
 	if l == 0 {
 		l = 1
 	}
-	fJhr, err := os.OpenFile(j.AreaPath+".jhr", os.O_RDWR, 0644)
+	lock, err := lockFile(j.AreaPath+".jhr", 0)
 	if err != nil {
 		return err
 	}
-	defer fJhr.Close()
+	defer lock.Unlock()
+	fJhr := lock.File
 	_, err = fJhr.Seek(int64(j.indexStructure[l-1].jamsh.Offset), 0)
 	if err != nil {
 		return err
@@ -626,6 +626,5 @@ func (j *JAM) DelMsg(l uint32) error {
 	if err != nil {
 		return err
 	}
-	fJhr.Close()
 	return nil
 }
@@ -0,0 +1,200 @@
+package msgapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/askovpen/gossiped/pkg/config"
+	"github.com/askovpen/gossiped/pkg/utils"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// PackOptions pack and purge rules
+type PackOptions struct {
+	Renumber bool
+	MaxAge   time.Duration
+	MaxMsgs  uint32
+}
+
+// PackStats pack result
+type PackStats struct {
+	Before, After, Deleted, Purged uint32
+}
+
+type jamPacked struct {
+	hdr       jamH
+	subfields []byte
+	text      []byte
+	toCRC     uint32
+}
+
+// ConfigPackOptions pack rules from config
+func ConfigPackOptions() PackOptions {
+	return PackOptions{
+		Renumber: config.Config.Purge.Renumber,
+		MaxAge:   time.Duration(config.Config.Purge.Days) * 24 * time.Hour,
+		MaxMsgs:  config.Config.Purge.MaxMsgs,
+	}
+}
+
+const (
+	jamHdrLen  = 76
+	jamDeleted = 0x80000000
+)
+
+// Pack rewrite JAM area without deleted and purged messages
+func (j *JAM) Pack(opts PackOptions) (stats PackStats, err error) {
+	lock, err := lockFile(j.AreaPath+".jhr", 0)
+	if err != nil {
+		return stats, err
+	}
+	defer lock.Unlock()
+	j.readIndex(lock.File)
+	if len(j.indexStructure) == 0 {
+		return stats, nil
+	}
+	jhr := j.headerStructure
+	if jhr.Signature != 0x4d414a {
+		return stats, errors.New("wrong JAM header signature")
+	}
+	fJdt, err := os.Open(j.AreaPath + ".jdt")
+	if err != nil {
+		return stats, err
+	}
+	defer fJdt.Close()
+	stats.Before = uint32(len(j.indexStructure))
+	var msgs []jamPacked
+	for _, is := range j.indexStructure {
+		header := make([]byte, jamHdrLen)
+		if _, err = lock.ReadAt(header, int64(is.jamsh.Offset)); err != nil {
+			return stats, err
+		}
+		var m jamPacked
+		if err = utils.ReadStructFromBuffer(bytes.NewBuffer(header), &m.hdr); err != nil {
+			return stats, err
+		}
+		if m.hdr.Signature != 0x4d414a {
+			return stats, errors.New("wrong message signature")
+		}
+		if m.hdr.Attribute&jamDeleted != 0 {
+			stats.Deleted++
+			continue
+		}
+		if opts.MaxAge > 0 && time.Since(time.Unix(int64(m.hdr.DateWritten), 0)) > opts.MaxAge {
+			stats.Purged++
+			continue
+		}
+		m.subfields = make([]byte, m.hdr.SubfieldLen)
+		if _, err = lock.ReadAt(m.subfields, int64(is.jamsh.Offset)+jamHdrLen); err != nil {
+			return stats, err
+		}
+		m.text = make([]byte, m.hdr.TxtLen)
+		if _, err = fJdt.ReadAt(m.text, int64(m.hdr.Offset)); err != nil && err != io.EOF {
+			return stats, err
+		}
+		m.toCRC = is.jamsh.ToCRC
+		msgs = append(msgs, m)
+	}
+	fJdt.Close()
+	if opts.MaxMsgs > 0 && uint32(len(msgs)) > opts.MaxMsgs {
+		stats.Purged += uint32(len(msgs)) - opts.MaxMsgs
+		msgs = msgs[uint32(len(msgs))-opts.MaxMsgs:]
+	}
+	stats.After = uint32(len(msgs))
+	renum := make(map[uint32]uint32)
+	var oldNums []uint32
+	for i, m := range msgs {
+		oldNums = append(oldNums, m.hdr.MessageNumber)
+		if opts.Renumber {
+			renum[m.hdr.MessageNumber] = uint32(i) + 1
+		} else {
+			renum[m.hdr.MessageNumber] = m.hdr.MessageNumber
+		}
+	}
+	if opts.Renumber || len(msgs) == 0 {
+		jhr.BaseMsgNum = 1
+	} else {
+		jhr.BaseMsgNum = msgs[0].hdr.MessageNumber
+	}
+	jhr.ActiveMsgs = uint32(len(msgs))
+	jhr.ModCounter++
+	hdrBuf, txtBuf, idxBuf := new(bytes.Buffer), new(bytes.Buffer), new(bytes.Buffer)
+	if err = utils.WriteStructToBuffer(hdrBuf, &jhr); err != nil {
+		return stats, err
+	}
+	next := jhr.BaseMsgNum
+	for _, m := range msgs {
+		num := renum[m.hdr.MessageNumber]
+		for ; next < num; next++ {
+			utils.WriteStructToBuffer(idxBuf, &jamSH{0xffffffff, 0xffffffff})
+		}
+		next++
+		m.hdr.MessageNumber = num
+		m.hdr.ReplyTo = renum[m.hdr.ReplyTo]
+		m.hdr.Reply1st = renum[m.hdr.Reply1st]
+		m.hdr.ReplyNext = renum[m.hdr.ReplyNext]
+		m.hdr.Offset = uint32(txtBuf.Len())
+		txtBuf.Write(m.text)
+		if err = utils.WriteStructToBuffer(idxBuf, &jamSH{m.toCRC, uint32(hdrBuf.Len())}); err != nil {
+			return stats, err
+		}
+		if err = utils.WriteStructToBuffer(hdrBuf, &m.hdr); err != nil {
+			return stats, err
+		}
+		hdrBuf.Write(m.subfields)
+	}
+	for _, f := range []struct {
+		ext string
+		buf *bytes.Buffer
+	}{{".jdt", txtBuf}, {".jdx", idxBuf}} {
+		if err = ioutil.WriteFile(j.AreaPath+f.ext+".tmp", f.buf.Bytes(), 0644); err != nil {
+			return stats, err
+		}
+		if err = os.Rename(j.AreaPath+f.ext+".tmp", j.AreaPath+f.ext); err != nil {
+			return stats, err
+		}
+	}
+	// .jhr stays open and locked, so it is rewritten in place
+	if err = lock.Truncate(0); err != nil {
+		return stats, err
+	}
+	if _, err = lock.WriteAt(hdrBuf.Bytes(), 0); err != nil {
+		return stats, err
+	}
+	j.indexStructure = nil
+	j.lastRead = nil
+	j.messages = nil
+	forgetThread(j)
+	j.readIndex(lock.File)
+	return stats, j.packLastRead(oldNums, renum)
+}
+
+// packLastRead move lastread pointers to nearest kept message
+func (j *JAM) packLastRead(oldNums []uint32, renum map[uint32]uint32) error {
+	j.readJLR()
+	if len(j.lastRead) == 0 {
+		return nil
+	}
+	remap := func(n uint32) uint32 {
+		ret := uint32(0)
+		for _, o := range oldNums {
+			if o > n {
+				break
+			}
+			ret = renum[o]
+		}
+		return ret
+	}
+	for i := range j.lastRead {
+		j.lastRead[i].LastReadMsg = remap(j.lastRead[i].LastReadMsg)
+		j.lastRead[i].HighReadMsg = remap(j.lastRead[i].HighReadMsg)
+	}
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, j.lastRead); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(j.AreaPath+".jlr", buf.Bytes(), 0644)
+}
@@ -0,0 +1,100 @@
+package msgapi
+
+import (
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestJAMPack(t *testing.T) {
+	os.MkdirAll("../../testdata/packtest", 0755)
+	Area := &JAM{AreaPath: "../../testdata/packtest/jam", AreaName: "test", AreaType: EchoAreaTypeEcho}
+	Areas = Areas[:0]
+	Areas = append(Areas, Area)
+	testMsg := func(i int) *Message {
+		return &Message{
+			From:        "SysOp",
+			To:          "All",
+			Subject:     strconv.Itoa(i),
+			FromAddr:    types.AddrFromNum(2, 5020, 9696, 1),
+			ToAddr:      &types.FidoAddr{},
+			DateWritten: time.Now().Add(time.Duration(i-6) * 24 * time.Hour),
+			DateArrived: time.Now(),
+			Body:        "Test " + strconv.Itoa(i) + "\x0d * Origin: test (2:5020/9696.1)\x0d",
+			Kludges:     make(map[string]string),
+		}
+	}
+	for i := 1; i <= 5; i++ {
+		Area.SaveMsg(testMsg(i))
+	}
+	g := Goblin(t)
+	g.Describe("Check JAM pack", func() {
+		g.It("pack deleted and renumber", func() {
+			Area.DelMsg(2)
+			Area.SetLast(4)
+			stats, err := Area.Pack(PackOptions{Renumber: true})
+			g.Assert(err).Equal(nil)
+			g.Assert(stats).Equal(PackStats{Before: 5, After: 4, Deleted: 1})
+			g.Assert(Area.GetCount()).Equal(uint32(4))
+			g.Assert(Area.indexStructure[1].MessageNum).Equal(uint32(2))
+			m, err := Area.GetMsg(2)
+			g.Assert(err).Equal(nil)
+			g.Assert(m.Subject).Equal("3")
+			g.Assert(m.Body).Equal("Test 3\x0d * Origin: test (2:5020/9696.1)\x0d")
+			g.Assert(Area.GetLast()).Equal(uint32(3))
+		})
+		g.It("pack preserving numbers", func() {
+			Area.DelMsg(1)
+			_, err := Area.Pack(PackOptions{})
+			g.Assert(err).Equal(nil)
+			fresh := &JAM{AreaPath: "../../testdata/packtest/jam", AreaName: "test", AreaType: EchoAreaTypeEcho}
+			g.Assert(fresh.GetCount()).Equal(uint32(3))
+			g.Assert(fresh.indexStructure[0].MessageNum).Equal(uint32(2))
+			m, _ := fresh.GetMsg(1)
+			g.Assert(m.Subject).Equal("3")
+		})
+		g.It("purge by age and count", func() {
+			stats, err := Area.Pack(PackOptions{MaxAge: 60 * time.Hour})
+			g.Assert(err).Equal(nil)
+			g.Assert(stats.Purged).Equal(uint32(1))
+			stats, err = Area.Pack(PackOptions{MaxMsgs: 1, Renumber: true})
+			g.Assert(err).Equal(nil)
+			g.Assert(stats).Equal(PackStats{Before: 2, After: 1, Purged: 1})
+			m, _ := Area.GetMsg(1)
+			g.Assert(m.Subject).Equal("5")
+		})
+		g.It("pack detached and reload", func() {
+			save := &JAM{AreaPath: "../../testdata/packtest/jam", AreaName: "test", AreaType: EchoAreaTypeEcho}
+			save.SaveMsg(testMsg(6))
+			save.DelMsg(1)
+			g.Assert(Area.GetCount()).Equal(uint32(1))
+			_, err := Detached(Area).(Packer).Pack(PackOptions{Renumber: true})
+			g.Assert(err).Equal(nil)
+			Reload(Area)
+			g.Assert(Area.GetCount()).Equal(uint32(1))
+			m, _ := Area.GetMsg(1)
+			g.Assert(m.Subject).Equal("6")
+		})
+		g.It("save after pack preserving numbers", func() {
+			Kept := &JAM{AreaPath: "../../testdata/packtest/kept", AreaName: "kept", AreaType: EchoAreaTypeEcho}
+			Areas = append(Areas, Kept)
+			for i := 1; i <= 5; i++ {
+				Kept.SaveMsg(testMsg(i))
+			}
+			Kept.DelMsg(4)
+			_, err := Kept.Pack(PackOptions{})
+			g.Assert(err).Equal(nil)
+			g.Assert(Kept.SaveMsg(testMsg(6))).Equal(nil)
+			fresh := &JAM{AreaPath: "../../testdata/packtest/kept", AreaName: "kept", AreaType: EchoAreaTypeEcho}
+			g.Assert(fresh.GetCount()).Equal(uint32(5))
+			g.Assert(fresh.idxRecords).Equal(uint32(6))
+			g.Assert(fresh.indexStructure[4].MessageNum).Equal(uint32(6))
+			m, _ := fresh.GetMsg(5)
+			g.Assert(m.Subject).Equal("6")
+		})
+	})
+	os.RemoveAll("../../testdata/packtest")
+}
@@ -0,0 +1,70 @@
+package msgapi
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// LockTimeout how long writers wait for a base locked by other tools
+var LockTimeout = 30 * time.Second
+
+// ErrLocked returned when base stays locked longer than LockTimeout
+var ErrLocked = errors.New("message base is locked")
+
+const (
+	lockRetry  = 100 * time.Millisecond
+	staleFlags = 10 * time.Minute
+)
+
+// lockedFile file opened with one byte locked at offset
+type lockedFile struct {
+	*os.File
+	offset int64
+}
+
+// lockFile open file and lock one byte at offset, as JAM, Squish and Hudson
+// tools do, retrying until LockTimeout
+func lockFile(fn string, offset int64) (*lockedFile, error) {
+	f, err := os.OpenFile(fn, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	for start := time.Now(); ; time.Sleep(lockRetry) {
+		if err = lockRegion(f, offset); err == nil {
+			return &lockedFile{f, offset}, nil
+		}
+		if time.Since(start) > LockTimeout {
+			f.Close()
+			return nil, ErrLocked
+		}
+	}
+}
+
+// Unlock release lock and close file
+func (l *lockedFile) Unlock() error {
+	unlockRegion(l.File, l.offset)
+	return l.File.Close()
+}
+
+// lockFlag create lock flag file exclusively, retrying until LockTimeout,
+// flags left by crashed tools are removed after staleFlags
+func lockFlag(fn string) (func(), error) {
+	for start := time.Now(); ; time.Sleep(lockRetry) {
+		f, err := os.OpenFile(fn, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(fn) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if fi, err := os.Stat(fn); err == nil && time.Since(fi.ModTime()) > staleFlags {
+			os.Remove(fn)
+			continue
+		}
+		if time.Since(start) > LockTimeout {
+			return nil, ErrLocked
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package msgapi
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// open file description locks are not released when other descriptors of
+// the same file are closed, but still conflict with fcntl locks of other tools
+const setLk = unix.F_OFD_SETLK
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package msgapi
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+const setLk = unix.F_SETLK
@@ -0,0 +1,105 @@
+package msgapi
+
+import (
+	"bufio"
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// TestLockHolder holds lock of GOSSIPED_LOCK_FILE until stdin is closed,
+// locks taken in one process do not conflict with posix locks
+func TestLockHolder(t *testing.T) {
+	fn := os.Getenv("GOSSIPED_LOCK_FILE")
+	if fn == "" {
+		return
+	}
+	lock, err := lockFile(fn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout.WriteString("locked\n")
+	bufio.NewReader(os.Stdin).ReadString('\n')
+	lock.Unlock()
+}
+
+func holdLock(fn string) (func(), error) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLockHolder$")
+	cmd.Env = append(os.Environ(), "GOSSIPED_LOCK_FILE="+fn)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	if _, err = bufio.NewReader(out).ReadString('\n'); err != nil {
+		return nil, err
+	}
+	return func() {
+		in.Close()
+		cmd.Wait()
+	}, nil
+}
+
+func TestLock(t *testing.T) {
+	os.MkdirAll("../../testdata/locktest", 0755)
+	timeout := LockTimeout
+	LockTimeout = 200 * time.Millisecond
+	Area := &JAM{AreaPath: "../../testdata/locktest/jam", AreaName: "test", AreaType: EchoAreaTypeEcho}
+	Areas = Areas[:0]
+	Areas = append(Areas, Area)
+	msg := func() *Message {
+		return &Message{
+			From:        "SysOp",
+			To:          "All",
+			Subject:     "Test",
+			FromAddr:    types.AddrFromNum(2, 5020, 9696, 1),
+			ToAddr:      &types.FidoAddr{},
+			DateWritten: time.Now(),
+			DateArrived: time.Now(),
+			Body:        "Test\x0d * Origin: test (2:5020/9696.1)\x0d",
+			Kludges:     make(map[string]string),
+		}
+	}
+	g := Goblin(t)
+	g.Describe("Check locking", func() {
+		g.It("flag lock", func() {
+			unlock, err := lockFlag("../../testdata/locktest/test.lock")
+			g.Assert(err).Equal(nil)
+			_, err = lockFlag("../../testdata/locktest/test.lock")
+			g.Assert(err).Equal(ErrLocked)
+			unlock()
+			unlock, err = lockFlag("../../testdata/locktest/test.lock")
+			g.Assert(err).Equal(nil)
+			unlock()
+		})
+		g.It("save to locked base", func() {
+			unlock, err := holdLock(Area.AreaPath + ".jhr")
+			g.Assert(err).Equal(nil)
+			g.Assert(Area.SaveMsg(msg())).Equal(ErrLocked)
+			unlock()
+			g.Assert(Area.SaveMsg(msg())).Equal(nil)
+			g.Assert(Area.GetCount()).Equal(uint32(1))
+		})
+		g.It("reload index under lock", func() {
+			other := &JAM{AreaPath: "../../testdata/locktest/jam", AreaName: "test", AreaType: EchoAreaTypeEcho}
+			g.Assert(other.SaveMsg(msg())).Equal(nil)
+			g.Assert(Area.SaveMsg(msg())).Equal(nil)
+			fresh := &JAM{AreaPath: "../../testdata/locktest/jam", AreaName: "test", AreaType: EchoAreaTypeEcho}
+			g.Assert(fresh.GetCount()).Equal(uint32(3))
+			m, err := fresh.GetMsg(3)
+			g.Assert(err).Equal(nil)
+			g.Assert(m.Subject).Equal("Test")
+		})
+	})
+	LockTimeout = timeout
+	os.RemoveAll("../../testdata/locktest")
+}
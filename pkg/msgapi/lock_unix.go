@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package msgapi
+
+import (
+	"golang.org/x/sys/unix"
+	"io"
+	"os"
+)
+
+func lockRegion(f *os.File, offset int64) error {
+	return unix.FcntlFlock(f.Fd(), setLk, &unix.Flock_t{Type: unix.F_WRLCK, Whence: io.SeekStart, Start: offset, Len: 1})
+}
+
+func unlockRegion(f *os.File, offset int64) error {
+	return unix.FcntlFlock(f.Fd(), setLk, &unix.Flock_t{Type: unix.F_UNLCK, Whence: io.SeekStart, Start: offset, Len: 1})
+}
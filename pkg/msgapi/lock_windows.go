@@ -0,0 +1,16 @@
+package msgapi
+
+import (
+	"golang.org/x/sys/windows"
+	"os"
+)
+
+func lockRegion(f *os.File, offset int64) error {
+	ol := &windows.Overlapped{Offset: uint32(offset), OffsetHigh: uint32(offset >> 32)}
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+}
+
+func unlockRegion(f *os.File, offset int64) error {
+	ol := &windows.Overlapped{Offset: uint32(offset), OffsetHigh: uint32(offset >> 32)}
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+}
@@ -20,6 +20,8 @@ type MessageListItem struct {
 	To          string
 	Subject     string
 	DateWritten time.Time
+	// MSGID: and REPLY: kludges, threads are built from them
+	MsgID, Reply string
 }
 
 // Message struct
@@ -59,11 +61,17 @@ func (m *Message) ParseRaw() error {
 			m.Kludges["FMPT"] = l[6:]
 		} else if len(l) > 6 && l[0:7] == "\x01MSGID:" {
 			m.Kludges["MSGID:"] = strings.Trim(l[7:], " ")
+		} else if len(l) > 6 && l[0:7] == "\x01REPLY:" {
+			m.Kludges["REPLY:"] = strings.Trim(l[7:], " ")
+		} else if len(l) > 8 && l[0:9] == "\x01REPLYID:" {
+			m.Kludges["REPLYID:"] = strings.Trim(l[9:], " ")
 		} else if len(l) > 10 && l[0:11] == "\x20*\x20Origin: " {
 			//re := regexp.MustCompile(`\d+:\d+/\d+\.*\d*`)
 			if len(originRE.FindStringSubmatch(l)) > 0 {
 				m.Kludges["ORIGIN"] = originRE.FindStringSubmatch(l)[0]
 			}
+		} else if len(l) > 6 && l[0:7] == "\x01FLAGS " {
+			m.parseFlags(l[7:])
 		} else if len(l) > 5 && l[0:6] == "\x01CHRS:" {
 			m.Kludges["CHRS"] = strings.ToUpper(strings.Split(strings.Trim(l[6:], " "), " ")[0])
 		}
@@ -167,41 +175,7 @@ func (m *Message) ToView(showKludges bool) string {
 
 // ToEditNewView export view
 func (m *Message) ToEditNewView() string {
-	var nm []string
-	//	p := 0
-	r := strings.NewReplacer(
-		"@pseudo", m.To,
-		"@CFName", strings.Split(m.From, " ")[0])
-	for _, l := range config.Template {
-		if len(l) > 0 {
-			if l[0] == '@' {
-				if len(l) > 3 && l[0:4] == "@New" {
-					if len(l) == 4 {
-						nm = append(nm, "")
-					} else {
-						nm = append(nm, r.Replace(l[4:]))
-					}
-				} else if len(l) > 8 && l[0:9] == "@Position" {
-					//					p = len(nm)
-					if len(l) == 9 {
-						nm = append(nm, "")
-					} else {
-						nm = append(nm, r.Replace(l[9:]))
-					}
-				} else if len(l) > 6 && l[0:7] == "@CFName" {
-					nm = append(nm, r.Replace(l))
-				}
-			} else {
-				nm = append(nm, r.Replace(l))
-			}
-		} else {
-			nm = append(nm, l)
-		}
-	}
-	nm = append(nm, "--- "+config.Config.Tearline)
-	nm = append(nm, " * Origin: "+config.Config.Origin+" ("+m.FromAddr.String()+")")
-	//log.Printf("pp: %d", p)
-	return strings.Join(nm, "\n")
+	return m.editView(&TemplateData{Msg: m})
 }
 
 // GetForward get forward
@@ -238,7 +212,6 @@ func (m *Message) GetForward() []string {
 // GetQuote get quote
 func (m *Message) GetQuote() []string {
 	var nm []string
-	re := regexp.MustCompile(">+")
 	from := ""
 	for _, l := range strings.Split(m.From, " ") {
 		from += string(l[0])
@@ -248,116 +221,40 @@ func (m *Message) GetQuote() []string {
 			continue
 		} else if len(l) > 8 && l[0:9] == "SEEN-BY: " {
 			continue
-		} else if ind := re.FindStringIndex(l); ind != nil {
-			ind2 := strings.Index(l, "<")
-			if (ind2 == -1 || ind2 > ind[1]) && ind[0] < 6 {
-				//				if (ind[1]-ind[0])%2 == 0 {
-				//					nm = append(nm, l[0:ind[0]+1]+">"+l[ind[0]+1:])
-				//				} else {
-				nm = append(nm, l[0:ind[0]+1]+">"+l[ind[0]+1:])
-				//				}
-			} else {
-				nm = append(nm, " "+from+"> "+l)
-			}
+		} else if prefix := utils.QuotePrefix(l); prefix != "" {
+			ind := strings.Index(prefix, ">")
+			nm = append(nm, l[0:ind+1]+">"+l[ind+1:])
 		} else {
 			nm = append(nm, " "+from+"> "+l)
 		}
 	}
-	//log.Print(from)
-	return nm
+	if config.Config.Editor.NoReflow {
+		return nm
+	}
+	return utils.ReformatQuote(nm, quoteMargin())
+}
+
+// quoteMargin right margin of reflowed quotes
+func quoteMargin() int {
+	if config.Config.Editor.Margin > 0 {
+		return config.Config.Editor.Margin
+	}
+	return 79
 }
 
 // ToEditAnswerView export view
 func (m *Message) ToEditAnswerView(om *Message) string {
-	var nm []string
-	//p := 0
-	r := strings.NewReplacer(
-		"@pseudo", m.To,
-		"@CFName", strings.Split(m.From, " ")[0],
-		"@ODate", om.DateWritten.Format("02 Jan 06"),
-		"@OTime", om.DateWritten.Format("15:04:05"),
-		"@OName", om.From,
-		"@DName", om.To)
-	for _, l := range config.Template {
-		if len(l) > 0 {
-			if l[0] == '@' {
-				if len(l) > 15 && l[0:16] == "@Quoted@Position" {
-					//p = len(nm)
-					nm = append(nm, "")
-				} else if len(l) > 6 && l[0:7] == "@Quoted" {
-					if len(l) == 7 {
-						nm = append(nm, "")
-					} else {
-						nm = append(nm, r.Replace(l[7:]))
-					}
-				} else if len(l) > 8 && l[0:9] == "@Position" {
-					//p = len(nm)
-					if len(l) == 9 {
-						nm = append(nm, "")
-					} else {
-						nm = append(nm, r.Replace(l[9:]))
-					}
-				} else if len(l) > 5 && l[0:6] == "@Quote" {
-					nm = append(nm, om.GetQuote()...)
-				} else if len(l) > 6 && l[0:7] == "@CFName" {
-					nm = append(nm, r.Replace(l))
-				}
-			} else {
-				nm = append(nm, r.Replace(l))
-			}
-		} else {
-			nm = append(nm, l)
-		}
-	}
-	nm = append(nm, "--- "+config.Config.Tearline)
-	nm = append(nm, " * Origin: "+config.Config.Origin+" ("+m.FromAddr.String()+")")
-	return strings.Join(nm, "\n")
+	return m.editView(&TemplateData{Msg: m, Orig: om})
 }
 
 // ToEditForwardView export view
 func (m *Message) ToEditForwardView(om *Message) string {
-	var nm []string
-	//p := 0
-	r := strings.NewReplacer(
-		"@pseudo", m.To,
-		"@CFName", strings.Split(m.From, " ")[0],
-		"@ODate", om.DateWritten.Format("02 Jan 06"),
-		"@OTime", om.DateWritten.Format("15:04:05"),
-		"@OName", om.From,
-		"@OAddr", om.FromAddr.String(),
-		"@DName", om.To,
-		"@OEcho", Areas[om.AreaID].GetName(),
-		"@Subject", om.Subject,
-		"@CAddr", config.Config.Address.String(),
-		"@CName", config.Config.Username)
-	for _, l := range config.Template {
-		if len(l) > 0 {
-			if l[0] == '@' {
-				if len(l) > 7 && l[0:8] == "@Forward" {
-					if len(l) == 8 {
-						nm = append(nm, "")
-					} else {
-						nm = append(nm, r.Replace(l[8:]))
-					}
-				} else if len(l) > 8 && l[0:9] == "@Position" {
-					//p = len(nm)
-					if len(l) == 9 {
-						nm = append(nm, "")
-					} else {
-						nm = append(nm, r.Replace(l[9:]))
-					}
-				} else if len(l) > 7 && l[0:8] == "@Message" {
-					nm = append(nm, om.GetForward()...)
-				} else if len(l) > 6 && l[0:7] == "@CFName" {
-					nm = append(nm, r.Replace(l))
-				}
-			} else {
-				nm = append(nm, r.Replace(l))
-			}
-		} else {
-			nm = append(nm, l)
-		}
-	}
+	return m.editView(&TemplateData{Msg: m, Orig: om, Forward: true})
+}
+
+// editView fills template and appends tearline and origin
+func (m *Message) editView(d *TemplateData) string {
+	nm := ExecTemplate(config.Template, d)
 	nm = append(nm, "--- "+config.Config.Tearline)
 	nm = append(nm, " * Origin: "+config.Config.Origin+" ("+m.FromAddr.String()+")")
 	return strings.Join(nm, "\n")
@@ -379,6 +276,13 @@ func (m *Message) MakeBody() *Message {
 		if fromp > 0 {
 			m.Kludges["FMPT"] = strconv.FormatUint(uint64(fromp), 10)
 		}
+		if flags := m.flagsKludge(); flags != "" {
+			m.Kludges["FLAGS"] = flags
+		}
+	}
+	// written here, tossed messages keep attributes of packet
+	if !m.HasAttr("Loc") {
+		m.Attrs = append(m.Attrs, "Loc")
 	}
 	m.Kludges["MSGID:"] = fmt.Sprintf("%s %08x", m.FromAddr.String(), uint32(time.Now().Unix()))
 	m.Body = strings.Join(strings.Split(m.Body, "\n"), "\x0d") + "\x0d"
@@ -45,6 +45,9 @@ type msgS struct {
 	Body        string
 }
 
+// lock flag created in area directory while writing
+const msgLockFlag = "msgbase.lck"
+
 // MSGAttrs MSG attributes
 type MSGAttrs uint16
 
@@ -72,24 +75,24 @@ const (
 func (m *MSG) Init() {
 }
 
-func (m *MSG) getAttrs(a uint16) (attrs []string) {
-	datr := []string{
-		"Pvt", "", "Rcv", "Snt",
-		"", "Trs", "", "K/s",
-		"Loc", "", "", "",
-		"Rrq", "", "Arq", "",
-	}
-	i := 0
-	for a > 0 {
-		if a&1 > 0 {
-			if datr[i] != "" {
-				attrs = append(attrs, datr[i])
-			}
+func (m *MSG) getAttrs(a uint16) []string {
+	return getPktAttrs(a)
+}
+
+func (m *MSG) getOffsetByNum(num uint32) uint32 {
+	for i, n := range m.messageNums {
+		if n == num {
+			return uint32(i) + 1
 		}
-		i++
-		a >>= 1
 	}
-	return
+	return 0
+}
+
+func (m *MSG) getNumByOffset(offset uint32) uint16 {
+	if offset == 0 || offset > uint32(len(m.messageNums)) {
+		return 0
+	}
+	return uint16(m.messageNums[offset-1])
 }
 
 func parseDate(date string) (ret time.Time) {
@@ -130,6 +133,14 @@ func (m *MSG) GetMsg(position uint32) (*Message, error) {
 		DateWritten: parseDate(strings.Trim(string(msgm.Date[:]), "\x00")),
 		DateArrived: getTime(msgm.DateArrived),
 		Attrs:       m.getAttrs(uint16(msgm.Attr))}
+	if msgm.Reply > 0 {
+		rm.ReplyTo = m.getOffsetByNum(uint32(msgm.Reply))
+	}
+	if msgm.Up > 0 {
+		if up := m.getOffsetByNum(uint32(msgm.Up)); up > 0 {
+			rm.Replies = append(rm.Replies, up)
+		}
+	}
 	err = rm.ParseRaw()
 	if err != nil {
 		return nil, err
@@ -220,15 +231,28 @@ func (m *MSG) SetLast(l uint32) {
 }
 
 // SaveMsg save message
-func (m *MSG) SaveMsg(tm *Message) error {
+func (m *MSG) SaveMsg(tm *Message) (err error) {
+	key, err := checkDupe(m.AreaName, m.AreaType, tm)
+	if err != nil {
+		return err
+	}
+	defer addDupe(m.AreaType, key, &err)
 	if _, err := os.Stat(m.AreaPath); os.IsNotExist(err) {
 		err = os.MkdirAll(m.AreaPath, 0755)
 		if err != nil {
 			return err
 		}
 	}
+	unlock, err := lockFlag(filepath.Join(m.AreaPath, msgLockFlag))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	m.messageNums = nil
+	m.readMN()
 	tm.Encode()
-	msgm := msgS{Attr: MSGLOCAL,
+	msgm := msgS{Attr: MSGAttrs(getPktAttrNum(tm.Attrs)),
+		Reply:       m.getNumByOffset(tm.ReplyTo),
 		DateWritten: setTime(tm.DateWritten),
 		DateArrived: setTime(tm.DateArrived),
 		DestNode:    tm.ToAddr.GetNode(),
@@ -236,6 +260,9 @@ func (m *MSG) SaveMsg(tm *Message) error {
 		OrigNode:    tm.FromAddr.GetNode(),
 		OrigNet:     tm.FromAddr.GetNet(),
 		Body:        tm.Body}
+	if len(tm.Replies) > 0 {
+		msgm.Up = m.getNumByOffset(tm.Replies[0])
+	}
 	copy(msgm.From[:], tm.From)
 	copy(msgm.To[:], tm.To)
 	copy(msgm.Subj[:], tm.Subject)
@@ -245,7 +272,7 @@ func (m *MSG) SaveMsg(tm *Message) error {
 	}
 	msgm.Body += "\x00"
 	buf := new(bytes.Buffer)
-	err := utils.WriteStructToBuffer(buf, &msgm)
+	err = utils.WriteStructToBuffer(buf, &msgm)
 	if err != nil {
 		return err
 	}
@@ -268,9 +295,24 @@ func (m *MSG) SaveMsg(tm *Message) error {
 	} else {
 		m.messageNums = append(m.messageNums, m.messageNums[len(m.messageNums)-1]+1)
 	}
+	if msgm.Reply > 0 {
+		m.linkReply(uint32(msgm.Reply), m.messageNums[len(m.messageNums)-1])
+	}
 	return nil
 }
 
+// linkReply set Up link of parent to its first reply
+func (m *MSG) linkReply(parent, reply uint32) {
+	b, err := ioutil.ReadFile(m.msgFile(parent))
+	if err != nil || len(b) < msgHdrLen || binary.LittleEndian.Uint16(b[msgUpOffset:]) != 0 {
+		return
+	}
+	binary.LittleEndian.PutUint16(b[msgUpOffset:], uint16(reply))
+	if err = ioutil.WriteFile(m.msgFile(parent), b, 0644); err != nil {
+		log.Print(err)
+	}
+}
+
 // SetChrs set charset
 func (m *MSG) SetChrs(s string) {
 	m.Chrs = s
@@ -283,22 +325,10 @@ func (m *MSG) GetChrs() string {
 
 // GetMessages get headers
 func (m *MSG) GetMessages() *[]MessageListItem {
-	if len(m.messages) > 0 || len(m.messageNums) == 0 {
+	if m.GetCount() == 0 || uint32(len(m.messages)) == m.GetCount() {
 		return &m.messages
 	}
-	for i := uint32(0); i < m.GetCount(); i++ {
-		mm, err := m.GetMsg(i + 1)
-		if err != nil {
-			continue
-		}
-		m.messages = append(m.messages, MessageListItem{
-			MsgNum:      i + 1,
-			From:        mm.From,
-			To:          mm.To,
-			Subject:     mm.Subject,
-			DateWritten: mm.DateWritten,
-		})
-	}
+	m.messages = readMessageList(m)
 	return &m.messages
 }
 
@@ -307,7 +337,12 @@ func (m *MSG) DelMsg(l uint32) error {
 	if l == 0 {
 		l = 1
 	}
-	err := os.Remove(filepath.Join(m.AreaPath, strconv.FormatUint(uint64(m.messageNums[l-1]), 10)+".msg"))
+	unlock, err := lockFlag(filepath.Join(m.AreaPath, msgLockFlag))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = os.Remove(filepath.Join(m.AreaPath, strconv.FormatUint(uint64(m.messageNums[l-1]), 10)+".msg"))
 	if err != nil {
 		return err
 	}
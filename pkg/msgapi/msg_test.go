@@ -52,6 +52,21 @@ func TestMSG(t *testing.T) {
 			g.Assert(Area.GetLast()).Equal(uint32(1))
 			g.Assert(len(*Area.GetMessages())).Equal(2)
 		})
+		g.It("attrs and reply links", func() {
+			r := *m
+			r.Subject = "Re: Test"
+			r.Attrs = []string{"Pvt", "Cra", "Att", "Hld", "Frq"}
+			r.ReplyTo = 1
+			g.Assert(Area.SaveMsg(&r)).Equal(nil)
+			nm, err := Area.GetMsg(3)
+			g.Assert(err).Equal(nil)
+			g.Assert(nm.Attrs).Equal([]string{"Pvt", "Cra", "Att", "Hld", "Frq"})
+			g.Assert(nm.ReplyTo).Equal(uint32(1))
+			nm, _ = Area.GetMsg(1)
+			g.Assert(nm.Attrs).Equal([]string{"Loc"})
+			g.Assert(nm.Replies).Equal([]uint32{3})
+			g.Assert(Area.DelMsg(3)).Equal(nil)
+		})
 		g.It("del msg", func() {
 			err := Area.DelMsg(2)
 			g.Assert(err).Equal(nil)
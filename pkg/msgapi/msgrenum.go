@@ -0,0 +1,139 @@
+package msgapi
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// offsets of reply links in *.msg header
+const (
+	msgReplyOffset = 184
+	msgUpOffset    = 188
+	msgHdrLen      = 190
+)
+
+func (m *MSG) msgFile(num uint32) string {
+	return filepath.Join(m.AreaPath, strconv.FormatUint(uint64(num), 10)+".msg")
+}
+
+// Renumber compact *.msg files to 1..N, fixing reply links and lastread
+func (m *MSG) Renumber() error {
+	unlock, err := lockFlag(filepath.Join(m.AreaPath, msgLockFlag))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return m.renumber()
+}
+
+func (m *MSG) renumber() error {
+	m.messageNums = nil
+	m.readMN()
+	renum := make(map[uint32]uint32)
+	for i, n := range m.messageNums {
+		renum[n] = uint32(i) + 1
+	}
+	for i, n := range m.messageNums {
+		b, err := ioutil.ReadFile(m.msgFile(n))
+		if err != nil {
+			return err
+		}
+		if len(b) >= msgHdrLen {
+			for _, off := range []int{msgReplyOffset, msgUpOffset} {
+				link := uint32(binary.LittleEndian.Uint16(b[off:]))
+				binary.LittleEndian.PutUint16(b[off:], uint16(renum[link]))
+			}
+		}
+		fn := m.msgFile(uint32(i) + 1)
+		if err = ioutil.WriteFile(fn+".tmp", b, 0644); err != nil {
+			return err
+		}
+		if err = os.Rename(fn+".tmp", fn); err != nil {
+			return err
+		}
+		if n != uint32(i)+1 {
+			if err = os.Remove(m.msgFile(n)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := m.renumberLastRead(); err != nil {
+		return err
+	}
+	for i := range m.messageNums {
+		m.messageNums[i] = uint32(i) + 1
+	}
+	m.messages = nil
+	forgetThread(m)
+	return nil
+}
+
+// renumberLastRead move every lastread record to the new number of
+// nearest message at or before it
+func (m *MSG) renumberLastRead() error {
+	fn := filepath.Join(m.AreaPath, "lastread")
+	b, err := ioutil.ReadFile(fn)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for off := 0; off+2 <= len(b); off += 2 {
+		lr := uint32(binary.LittleEndian.Uint16(b[off:]))
+		nl := uint32(0)
+		for i, n := range m.messageNums {
+			if n > lr {
+				break
+			}
+			nl = uint32(i) + 1
+		}
+		binary.LittleEndian.PutUint16(b[off:], uint16(nl))
+	}
+	return ioutil.WriteFile(fn, b, 0644)
+}
+
+// Pack purge MSG area by age and count, renumbering files if asked
+func (m *MSG) Pack(opts PackOptions) (stats PackStats, err error) {
+	unlock, err := lockFlag(filepath.Join(m.AreaPath, msgLockFlag))
+	if err != nil {
+		return stats, err
+	}
+	defer unlock()
+	m.messageNums = nil
+	m.readMN()
+	stats.Before = uint32(len(m.messageNums))
+	var kept []uint32
+	for i, n := range m.messageNums {
+		if opts.MaxAge > 0 {
+			msg, err := m.GetMsg(uint32(i) + 1)
+			if err == nil && msg != nil && time.Since(msg.DateWritten) > opts.MaxAge {
+				if err = os.Remove(m.msgFile(n)); err != nil {
+					return stats, err
+				}
+				continue
+			}
+		}
+		kept = append(kept, n)
+	}
+	if opts.MaxMsgs > 0 && uint32(len(kept)) > opts.MaxMsgs {
+		for _, n := range kept[:uint32(len(kept))-opts.MaxMsgs] {
+			if err = os.Remove(m.msgFile(n)); err != nil {
+				return stats, err
+			}
+		}
+		kept = kept[uint32(len(kept))-opts.MaxMsgs:]
+	}
+	m.messageNums = kept
+	m.messages = nil
+	forgetThread(m)
+	stats.After = uint32(len(kept))
+	stats.Purged = stats.Before - stats.After
+	if opts.Renumber {
+		return stats, m.renumber()
+	}
+	return stats, nil
+}
@@ -0,0 +1,74 @@
+package msgapi
+
+import (
+	"encoding/binary"
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMSGRenumber(t *testing.T) {
+	Area := &MSG{AreaPath: "../../testdata/renumtest", AreaName: "test", AreaType: EchoAreaTypeEcho}
+	Areas = Areas[:0]
+	Areas = append(Areas, Area)
+	for i := 1; i <= 5; i++ {
+		Area.SaveMsg(&Message{
+			From:        "SysOp",
+			To:          "All",
+			Subject:     strconv.Itoa(i),
+			FromAddr:    types.AddrFromNum(2, 5020, 9696, 1),
+			ToAddr:      &types.FidoAddr{},
+			DateWritten: time.Now(),
+			DateArrived: time.Now(),
+			Body:        "Test\x0d * Origin: test (2:5020/9696.1)\x0d",
+			Kludges:     make(map[string]string),
+		})
+	}
+	link := func(num uint32, off int, val uint16) uint16 {
+		b, _ := ioutil.ReadFile(Area.msgFile(num))
+		if val > 0 {
+			binary.LittleEndian.PutUint16(b[off:], val)
+			ioutil.WriteFile(Area.msgFile(num), b, 0644)
+		}
+		return binary.LittleEndian.Uint16(b[off:])
+	}
+	link(1, msgUpOffset, 3)
+	link(3, msgReplyOffset, 1)
+	link(5, msgReplyOffset, 4)
+	g := Goblin(t)
+	g.Describe("Check MSG renumber", func() {
+		g.It("renumber", func() {
+			Area.DelMsg(4)
+			Area.DelMsg(2)
+			Area.SetLast(2)
+			g.Assert(Area.Renumber()).Equal(nil)
+			for n, exists := range []bool{true, true, true, false, false} {
+				_, err := os.Stat(Area.msgFile(uint32(n) + 1))
+				g.Assert(err == nil).Equal(exists)
+			}
+			m, _ := Area.GetMsg(2)
+			g.Assert(m.Subject).Equal("3")
+			g.Assert(Area.GetLast()).Equal(uint32(2))
+			b, _ := ioutil.ReadFile("../../testdata/renumtest/lastread")
+			g.Assert(binary.LittleEndian.Uint16(b)).Equal(uint16(2))
+		})
+		g.It("reply links", func() {
+			g.Assert(link(1, msgUpOffset, 0)).Equal(uint16(2))
+			g.Assert(link(2, msgReplyOffset, 0)).Equal(uint16(1))
+			g.Assert(link(3, msgReplyOffset, 0)).Equal(uint16(0))
+		})
+		g.It("pack by count", func() {
+			stats, err := Area.Pack(PackOptions{MaxMsgs: 2, Renumber: true})
+			g.Assert(err).Equal(nil)
+			g.Assert(stats).Equal(PackStats{Before: 3, After: 2, Purged: 1})
+			m, _ := Area.GetMsg(1)
+			g.Assert(m.Subject).Equal("3")
+			g.Assert(Area.GetLast()).Equal(uint32(1))
+		})
+	})
+	os.RemoveAll("../../testdata/renumtest")
+}
@@ -0,0 +1,487 @@
+package msgapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/askovpen/gossiped/pkg/config"
+	"github.com/askovpen/gossiped/pkg/types"
+	"github.com/askovpen/gossiped/pkg/utils"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PKT read-only packet area, AreaPath is a packet file or a directory of packets
+type PKT struct {
+	AreaPath string
+	AreaName string
+	AreaType EchoAreaType
+	Chrs     string
+	index    []pktMsg
+	last     uint32
+	messages []MessageListItem
+}
+
+// PktHeader packet header
+type PktHeader struct {
+	Type     string
+	OrigAddr *types.FidoAddr
+	DestAddr *types.FidoAddr
+	Date     time.Time
+	Password string
+	ProdCode uint16
+}
+
+type pktMsg struct {
+	OrigNode, DestNode, OrigNet, DestNet, Attr, Cost uint16
+	Date, To, From, Subject, Body                    string
+	header                                           *PktHeader
+}
+
+const pktHdrLen = 58
+
+// ErrReadOnly returned on write to read-only area
+var ErrReadOnly = errors.New("read-only area")
+
+var pktAttrs = []string{
+	"Pvt", "Cra", "Rcv", "Snt",
+	"Att", "Trs", "Orp", "K/s",
+	"Loc", "Hld", "", "Frq",
+	"Rrq", "Cpt", "Arq", "Urq",
+}
+
+func getPktAttrs(a uint16) (attrs []string) {
+	for i := 0; a > 0; i++ {
+		if a&1 > 0 && pktAttrs[i] != "" {
+			attrs = append(attrs, pktAttrs[i])
+		}
+		a >>= 1
+	}
+	return
+}
+
+func parsePktHeader(h []byte) (*PktHeader, error) {
+	if len(h) < pktHdrLen {
+		return nil, errors.New("short packet header")
+	}
+	w := func(o int) uint16 { return binary.LittleEndian.Uint16(h[o:]) }
+	if w(18) != 2 {
+		return nil, errors.New("unsupported packet version")
+	}
+	ph := &PktHeader{
+		Password: strings.TrimRight(string(h[26:34]), "\x00"),
+		ProdCode: uint16(h[24]),
+	}
+	origNet, destNet := w(20), w(22)
+	if w(16) == 2 {
+		ph.Type = "2.2"
+		ph.OrigAddr = types.AddrFromNum(w(34), origNet, w(0), w(4))
+		ph.DestAddr = types.AddrFromNum(w(36), destNet, w(2), w(6))
+		return ph, nil
+	}
+	ph.Date = time.Date(int(w(4)), time.Month(w(6)+1), int(w(8)), int(w(10)), int(w(12)), int(w(14)), 0, time.Local)
+	cw := w(44)
+	if cw == w(40)>>8|w(40)<<8 && cw&1 > 0 {
+		ph.Type = "2+"
+		ph.ProdCode |= uint16(h[42]) << 8
+		origZone, destZone := w(46), w(48)
+		if origZone == 0 {
+			origZone = w(34)
+		}
+		if destZone == 0 {
+			destZone = w(36)
+		}
+		if origNet == 0xffff && w(50) != 0 {
+			origNet = w(38)
+		}
+		ph.OrigAddr = types.AddrFromNum(origZone, origNet, w(0), w(50))
+		ph.DestAddr = types.AddrFromNum(destZone, destNet, w(2), w(52))
+		return ph, nil
+	}
+	ph.Type = "2"
+	ph.OrigAddr = types.AddrFromNum(w(34), origNet, w(0), 0)
+	ph.DestAddr = types.AddrFromNum(w(36), destNet, w(2), 0)
+	return ph, nil
+}
+
+func readPktString(r *bytes.Reader, max int) string {
+	var s []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil || c == 0 {
+			break
+		}
+		if max == 0 || len(s) < max {
+			s = append(s, c)
+		}
+	}
+	return string(s)
+}
+
+func parsePktDate(date string) time.Time {
+	ret := parseDate(date)
+	if ret.IsZero() {
+		ret, _ = time.Parse("Mon _2 Jan 06 15:04", date)
+	}
+	return ret
+}
+
+// ReadPkt parse packet file
+func ReadPkt(fn string) (*PktHeader, []*Message, error) {
+	ph, msgs, err := readPkt(fn)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ret []*Message
+	for _, pm := range msgs {
+		ret = append(ret, pm.toMessage())
+	}
+	return ph, ret, nil
+}
+
+func readPkt(fn string) (*PktHeader, []pktMsg, error) {
+	b, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return nil, nil, err
+	}
+	ph, err := parsePktHeader(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	var msgs []pktMsg
+	r := bytes.NewReader(b[pktHdrLen:])
+	for {
+		var t uint16
+		if err = binary.Read(r, binary.LittleEndian, &t); err != nil || t == 0 {
+			break
+		}
+		if t != 2 {
+			return ph, msgs, errors.New("wrong packed message type")
+		}
+		var pm pktMsg
+		for _, f := range []*uint16{&pm.OrigNode, &pm.DestNode, &pm.OrigNet, &pm.DestNet, &pm.Attr, &pm.Cost} {
+			if err = binary.Read(r, binary.LittleEndian, f); err != nil {
+				return ph, msgs, err
+			}
+		}
+		pm.Date = readPktString(r, 20)
+		pm.To = readPktString(r, 36)
+		pm.From = readPktString(r, 36)
+		pm.Subject = readPktString(r, 72)
+		pm.Body = strings.Replace(readPktString(r, 0), "\x0a", "", -1)
+		pm.header = ph
+		msgs = append(msgs, pm)
+	}
+	return ph, msgs, nil
+}
+
+// toMessage convert packed message, echomail area tag stored in Area
+func (pm *pktMsg) toMessage() *Message {
+	m := &Message{
+		From:        pm.From,
+		To:          pm.To,
+		Subject:     pm.Subject,
+		Body:        pm.Body,
+		DateWritten: parsePktDate(strings.Trim(pm.Date, " ")),
+		DateArrived: pm.header.Date,
+		Attrs:       getPktAttrs(pm.Attr),
+	}
+	if strings.HasPrefix(m.Body, "AREA:") {
+		i := strings.Index(m.Body, "\x0d")
+		if i == -1 {
+			i = len(m.Body)
+		}
+		m.Area = strings.Trim(m.Body[5:i], " ")
+		m.Body = strings.TrimPrefix(m.Body[i:], "\x0d")
+	} else if !strings.Contains(m.Body, "\x01INTL ") {
+		m.FromAddr = types.AddrFromNum(pm.header.OrigAddr.GetZone(), pm.OrigNet, pm.OrigNode, 0)
+		m.ToAddr = types.AddrFromNum(pm.header.DestAddr.GetZone(), pm.DestNet, pm.DestNode, 0)
+	}
+	return m
+}
+
+func (p *PKT) readIndex() {
+	if len(p.index) > 0 {
+		return
+	}
+	files := []string{p.AreaPath}
+	if fi, err := os.Stat(p.AreaPath); err == nil && fi.IsDir() {
+		files = files[:0]
+		dir, err := ioutil.ReadDir(p.AreaPath)
+		if err != nil {
+			return
+		}
+		for _, f := range dir {
+			if !f.IsDir() && strings.EqualFold(filepath.Ext(f.Name()), ".pkt") {
+				files = append(files, filepath.Join(p.AreaPath, f.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+	for _, fn := range files {
+		_, msgs, _ := readPkt(fn)
+		p.index = append(p.index, msgs...)
+	}
+}
+
+// Init init
+func (p *PKT) Init() {
+}
+
+// GetMsg return msg
+func (p *PKT) GetMsg(position uint32) (*Message, error) {
+	p.readIndex()
+	if len(p.index) == 0 {
+		return nil, nil
+	}
+	if position == 0 {
+		position = 1
+	}
+	rm := p.index[position-1].toMessage()
+	if rm.Area == "" {
+		rm.Area = p.AreaName
+	}
+	rm.MsgNum = position
+	rm.MaxNum = uint32(len(p.index))
+	err := rm.ParseRaw()
+	if err != nil {
+		return nil, err
+	}
+	return rm, nil
+}
+
+// GetCount return count messages
+func (p *PKT) GetCount() uint32 {
+	p.readIndex()
+	return uint32(len(p.index))
+}
+
+// GetLast return last message, kept in memory only
+func (p *PKT) GetLast() uint32 {
+	return p.last
+}
+
+// SetLast set last message
+func (p *PKT) SetLast(l uint32) {
+	p.last = l
+}
+
+// GetMsgType return msg base type
+func (p *PKT) GetMsgType() EchoAreaMsgType {
+	return EchoAreaMsgTypePKT
+}
+
+// GetType return area type
+func (p *PKT) GetType() EchoAreaType {
+	return p.AreaType
+}
+
+// GetName return area name
+func (p *PKT) GetName() string {
+	return p.AreaName
+}
+
+// SetChrs set charset
+func (p *PKT) SetChrs(c string) {
+	p.Chrs = c
+}
+
+// GetChrs get charset
+func (p *PKT) GetChrs() string {
+	return p.Chrs
+}
+
+// DelMsg not supported
+func (p *PKT) DelMsg(l uint32) error {
+	return ErrReadOnly
+}
+
+// SaveMsg not supported
+func (p *PKT) SaveMsg(tm *Message) error {
+	return ErrReadOnly
+}
+
+// GetMessages get headers
+func (p *PKT) GetMessages() *[]MessageListItem {
+	p.readIndex()
+	if len(p.messages) > 0 || len(p.index) == 0 {
+		return &p.messages
+	}
+	for i := uint32(0); i < p.GetCount(); i++ {
+		m, err := p.GetMsg(i + 1)
+		if err != nil {
+			continue
+		}
+		p.messages = append(p.messages, messageListItem(i+1, m))
+	}
+	return &p.messages
+}
+
+func putPktString(b *bytes.Buffer, s string, max int) {
+	if len(s) > max {
+		s = s[:max]
+	}
+	b.WriteString(s)
+	b.WriteByte(0)
+}
+
+// WritePkt write type 2+ packet, echomail messages carry area tag in Area
+func WritePkt(fn string, h *PktHeader, msgs []*Message) error {
+	hdr := make([]byte, pktHdrLen)
+	put := func(o int, v uint16) { binary.LittleEndian.PutUint16(hdr[o:], v) }
+	if h.Date.IsZero() {
+		h.Date = time.Now()
+	}
+	put(0, h.OrigAddr.GetNode())
+	put(2, h.DestAddr.GetNode())
+	put(4, uint16(h.Date.Year()))
+	put(6, uint16(h.Date.Month()-1))
+	put(8, uint16(h.Date.Day()))
+	put(10, uint16(h.Date.Hour()))
+	put(12, uint16(h.Date.Minute()))
+	put(14, uint16(h.Date.Second()))
+	put(18, 2)
+	put(20, h.OrigAddr.GetNet())
+	put(22, h.DestAddr.GetNet())
+	hdr[24] = byte(h.ProdCode)
+	copy(hdr[26:34], h.Password)
+	put(34, h.OrigAddr.GetZone())
+	put(36, h.DestAddr.GetZone())
+	put(38, h.OrigAddr.GetNet())
+	put(40, 0x0100)
+	hdr[42] = byte(h.ProdCode >> 8)
+	put(44, 0x0001)
+	put(46, h.OrigAddr.GetZone())
+	put(48, h.DestAddr.GetZone())
+	put(50, h.OrigAddr.GetPoint())
+	put(52, h.DestAddr.GetPoint())
+	b := bytes.NewBuffer(hdr)
+	for _, m := range msgs {
+		from, to := h.OrigAddr, h.DestAddr
+		if m.Area == "" && m.FromAddr != nil && m.ToAddr != nil {
+			from, to = m.FromAddr, m.ToAddr
+		}
+		for _, v := range []uint16{2, from.GetNode(), to.GetNode(), from.GetNet(), to.GetNet(), getPktAttrNum(m.Attrs), 0} {
+			binary.Write(b, binary.LittleEndian, v)
+		}
+		putPktString(b, m.DateWritten.Format("02 Jan 06  15:04:05"), 19)
+		putPktString(b, m.To, 35)
+		putPktString(b, m.From, 35)
+		putPktString(b, m.Subject, 71)
+		if m.Area != "" {
+			b.WriteString("AREA:" + m.Area + "\x0d")
+		}
+		b.WriteString(strings.Replace(m.Body, "\x00", "", -1))
+		b.WriteByte(0)
+	}
+	b.Write([]byte{0, 0})
+	return ioutil.WriteFile(fn, b.Bytes(), 0644)
+}
+
+func getPktAttrNum(attrs []string) (a uint16) {
+	for _, attr := range attrs {
+		for i, d := range pktAttrs {
+			if d != "" && d == attr {
+				a |= 1 << uint(i)
+			}
+		}
+	}
+	return
+}
+
+func seenBy(addrs ...*types.FidoAddr) string {
+	sort.Slice(addrs, func(i, j int) bool {
+		if addrs[i].GetNet() == addrs[j].GetNet() {
+			return addrs[i].GetNode() < addrs[j].GetNode()
+		}
+		return addrs[i].GetNet() < addrs[j].GetNet()
+	})
+	var ret []string
+	net := -1
+	for i, a := range addrs {
+		if i > 0 && a.GetNet() == addrs[i-1].GetNet() && a.GetNode() == addrs[i-1].GetNode() {
+			continue
+		}
+		if int(a.GetNet()) == net {
+			ret = append(ret, strconv.FormatUint(uint64(a.GetNode()), 10))
+		} else {
+			ret = append(ret, fmt.Sprintf("%d/%d", a.GetNet(), a.GetNode()))
+			net = int(a.GetNet())
+		}
+	}
+	return strings.Join(ret, " ")
+}
+
+// CanExport reports whether messages of area go to outbound, only netmail and echo areas are exported
+func CanExport(areaID int) bool {
+	t := Areas[areaID].GetType()
+	return t == EchoAreaTypeNetmail || t == EchoAreaTypeEcho
+}
+
+// ExportPkt write message to new packet in outbound, SEEN-BY and PATH are regenerated
+func ExportPkt(tm *Message) (string, error) {
+	if config.Config.Outbound.Path == "" {
+		return "", errors.New("Config.Outbound.Path not defined")
+	}
+	if !CanExport(tm.AreaID) {
+		return "", errors.New("only netmail and echo areas are exported")
+	}
+	orig := config.Config.Address
+	dest := config.Config.Outbound.Uplink
+	if dest == nil {
+		dest = types.AddrFromNum(orig.GetZone(), orig.GetNet(), orig.GetNode(), 0)
+	}
+	m := *tm
+	m.Encode()
+	kludges := ""
+	for kl, v := range m.Kludges {
+		if kl != "FLAGS" {
+			kludges += "\x01" + kl + " " + v + "\x0d"
+		}
+	}
+	var body []string
+	for _, l := range strings.Split(m.Body, "\x0d") {
+		if !strings.HasPrefix(l, "SEEN-BY:") && !strings.HasPrefix(l, "\x01PATH:") {
+			body = append(body, l)
+		}
+	}
+	m.Body = kludges + strings.Join(body, "\x0d")
+	if Areas[m.AreaID].GetType() == EchoAreaTypeNetmail {
+		m.Area = ""
+		// attributes without packet bit are kept in FLAGS
+		if flags := m.flagsKludge(); flags != "" {
+			m.Body = "\x01FLAGS " + flags + "\x0d" + m.Body
+		}
+	} else {
+		m.Area = strings.ToUpper(Areas[m.AreaID].GetName())
+		m.Attrs = nil
+		if !strings.HasSuffix(m.Body, "\x0d") {
+			m.Body += "\x0d"
+		}
+		m.Body += "SEEN-BY: " + seenBy(orig, dest) + "\x0d"
+		m.Body += "\x01PATH: " + fmt.Sprintf("%d/%d", orig.GetNet(), orig.GetNode()) + "\x0d"
+	}
+	h := &PktHeader{
+		OrigAddr: orig,
+		DestAddr: dest,
+		Password: config.Config.Outbound.Password,
+		ProdCode: 0xfe,
+	}
+	var fn string
+	for n := uint32(time.Now().Unix()); ; n++ {
+		fn = filepath.Join(config.Config.Outbound.Path, fmt.Sprintf("%08x.pkt", n))
+		if !utils.FileExists(fn) && !utils.FileExists(fn+".tmp") {
+			break
+		}
+	}
+	if err := WritePkt(fn+".tmp", h, []*Message{&m}); err != nil {
+		return "", err
+	}
+	return fn, os.Rename(fn+".tmp", fn)
+}
@@ -0,0 +1,157 @@
+package msgapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/askovpen/gossiped/pkg/config"
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func makeTestPkt(body string) []byte {
+	h := make([]byte, pktHdrLen)
+	put := func(o int, v uint16) { binary.LittleEndian.PutUint16(h[o:], v) }
+	put(0, 9696)
+	put(2, 1)
+	put(4, 2020)
+	put(18, 2)
+	put(20, 5020)
+	put(22, 5020)
+	copy(h[26:], "secret")
+	put(34, 2)
+	put(36, 2)
+	put(40, 0x0100)
+	put(44, 0x0001)
+	put(46, 2)
+	put(48, 2)
+	put(50, 128)
+	b := bytes.NewBuffer(h)
+	for _, v := range []uint16{2, 9696, 1, 5020, 5020, 0x0101, 0} {
+		binary.Write(b, binary.LittleEndian, v)
+	}
+	b.WriteString("01 Jan 20  12:00:00\x00All\x00SysOp\x00Test\x00")
+	b.WriteString(body + "\x00")
+	b.Write([]byte{0, 0})
+	return b.Bytes()
+}
+
+func TestPKT(t *testing.T) {
+	os.MkdirAll("../../testdata/pkttest", 0755)
+	ioutil.WriteFile("../../testdata/pkttest/00000001.pkt", makeTestPkt("AREA:TEST\x0dHello\x0d * Origin: test (2:5020/9696.128)\x0d"), 0644)
+	ioutil.WriteFile("../../testdata/pkttest/00000002.PKT", makeTestPkt("\x01MSGID: 2:5020/9696.128 12345678\x0dNetmail\x0d"), 0644)
+	Area := &PKT{
+		AreaPath: "../../testdata/pkttest",
+		AreaName: "inbound",
+		AreaType: EchoAreaTypeNetmail,
+	}
+	Areas = Areas[:0]
+	Areas = append(Areas, Area)
+	g := Goblin(t)
+	g.Describe("Check PKT read", func() {
+		g.It("read header", func() {
+			ph, msgs, err := ReadPkt("../../testdata/pkttest/00000001.pkt")
+			g.Assert(err).Equal(nil)
+			g.Assert(ph.Type).Equal("2+")
+			g.Assert(ph.Password).Equal("secret")
+			g.Assert(ph.OrigAddr).Equal(types.AddrFromNum(2, 5020, 9696, 128))
+			g.Assert(len(msgs)).Equal(1)
+			g.Assert(msgs[0].Area).Equal("TEST")
+		})
+		g.It("check num msgs", func() {
+			g.Assert(Area.GetCount()).Equal(uint32(2))
+			g.Assert(len(*Area.GetMessages())).Equal(2)
+		})
+		g.It("read echomail", func() {
+			nm, err := Area.GetMsg(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(nm.FromAddr).Equal(types.AddrFromNum(2, 5020, 9696, 128))
+			g.Assert(nm.Subject).Equal("Test")
+			g.Assert(nm.Attrs).Equal([]string{"Pvt", "Loc"})
+		})
+		g.It("read netmail", func() {
+			nm, err := Area.GetMsg(2)
+			g.Assert(err).Equal(nil)
+			g.Assert(nm.Area).Equal("inbound")
+			g.Assert(nm.FromAddr).Equal(types.AddrFromNum(2, 5020, 9696, 0))
+			g.Assert(nm.ToAddr).Equal(types.AddrFromNum(2, 5020, 1, 0))
+			g.Assert(nm.Kludges["MSGID:"]).Equal("2:5020/9696.128 12345678")
+		})
+		g.It("read-only", func() {
+			g.Assert(Area.SaveMsg(&Message{})).Equal(ErrReadOnly)
+			g.Assert(Area.DelMsg(1)).Equal(ErrReadOnly)
+		})
+	})
+	os.RemoveAll("../../testdata/pkttest")
+}
+
+func TestPKTWrite(t *testing.T) {
+	os.MkdirAll("../../testdata/pktout", 0755)
+	config.Config.Address = types.AddrFromNum(2, 5020, 9696, 128)
+	config.Config.Outbound.Path = "../../testdata/pktout"
+	config.Config.Outbound.Password = "secret"
+	Echo := &JAM{AreaName: "test.echo", AreaType: EchoAreaTypeEcho}
+	Local := &JAM{AreaName: "test.local", AreaType: EchoAreaTypeLocal}
+	Netmail := &JAM{AreaName: "netmail", AreaType: EchoAreaTypeNetmail}
+	Areas = Areas[:0]
+	Areas = append(Areas, Echo, Local, Netmail)
+	g := Goblin(t)
+	g.Describe("Check PKT write", func() {
+		m := &Message{
+			AreaID:      0,
+			From:        "SysOp",
+			To:          "All",
+			Subject:     "Test",
+			FromAddr:    config.Config.Address,
+			DateWritten: time.Now(),
+			Body:        "Test\nBody\n * Origin: test (2:5020/9696.128)",
+			Kludges:     make(map[string]string),
+		}
+		m.MakeBody()
+		var fn string
+		g.It("export msg", func() {
+			var err error
+			fn, err = ExportPkt(m)
+			g.Assert(err).Equal(nil)
+			g.Assert(strings.HasSuffix(fn, ".pkt")).IsTrue()
+		})
+		g.It("read exported", func() {
+			ph, msgs, err := ReadPkt(fn)
+			g.Assert(err).Equal(nil)
+			g.Assert(ph.Type).Equal("2+")
+			g.Assert(ph.Password).Equal("secret")
+			g.Assert(ph.OrigAddr).Equal(types.AddrFromNum(2, 5020, 9696, 128))
+			g.Assert(ph.DestAddr).Equal(types.AddrFromNum(2, 5020, 9696, 0))
+			g.Assert(len(msgs)).Equal(1)
+			g.Assert(msgs[0].Area).Equal("TEST.ECHO")
+			g.Assert(msgs[0].Subject).Equal("Test")
+			g.Assert(strings.Contains(msgs[0].Body, "\x01MSGID: "+m.Kludges["MSGID:"]+"\x0d")).IsTrue()
+			g.Assert(strings.Contains(msgs[0].Body, "SEEN-BY: 5020/9696\x0d\x01PATH: 5020/9696\x0d")).IsTrue()
+		})
+		g.It("netmail attributes exported", func() {
+			nm := *m
+			nm.AreaID = 2
+			nm.ToAddr = types.AddrFromNum(2, 5020, 1, 0)
+			nm.Attrs = []string{"Pvt", "Cra", "Loc", "Dir"}
+			nfn, err := ExportPkt(&nm)
+			g.Assert(err).Equal(nil)
+			_, msgs, err := ReadPkt(nfn)
+			g.Assert(err).Equal(nil)
+			g.Assert(msgs[0].Area).Equal("")
+			g.Assert(msgs[0].Attrs).Equal([]string{"Pvt", "Cra", "Loc"})
+			g.Assert(strings.HasPrefix(msgs[0].Body, "\x01FLAGS DIR\x0d")).IsTrue()
+		})
+		g.It("local area not exported", func() {
+			lm := *m
+			lm.AreaID = 1
+			g.Assert(CanExport(1)).IsFalse()
+			_, err := ExportPkt(&lm)
+			g.Assert(err == nil).IsFalse()
+		})
+	})
+	os.RemoveAll("../../testdata/pktout")
+}
@@ -0,0 +1,102 @@
+package msgapi
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// SearchQuery full-text search parameters
+type SearchQuery struct {
+	Text          string
+	Regexp        bool
+	CaseSensitive bool
+	Areas         []int
+}
+
+// SearchHit search result
+type SearchHit struct {
+	AreaID  int
+	MsgNum  uint32
+	From    string
+	Subject string
+	Line    string
+}
+
+// Compile return regexp for query
+func (q *SearchQuery) Compile() (*regexp.Regexp, error) {
+	expr := q.Text
+	if !q.Regexp {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if !q.CaseSensitive {
+		expr = "(?i)" + expr
+	}
+	return regexp.Compile(expr)
+}
+
+// AreasByMask return ids of areas with names matching shell mask
+func AreasByMask(mask string) (ret []int) {
+	mask = strings.ToLower(mask)
+	for i, a := range Areas {
+		if ok, _ := filepath.Match(mask, strings.ToLower(a.GetName())); ok {
+			ret = append(ret, i)
+		}
+	}
+	return
+}
+
+// SearchMessages scan headers and bodies of query areas, all areas if none given,
+// areas are read through Detached instances, so it is safe to call from goroutine.
+// hit is called for every matching message, progress before every area;
+// search stops when stop is closed
+func SearchMessages(q *SearchQuery, stop <-chan struct{}, hit func(SearchHit), progress func(areaID int)) error {
+	re, err := q.Compile()
+	if err != nil {
+		return err
+	}
+	areas := q.Areas
+	if len(areas) == 0 {
+		for i := range Areas {
+			areas = append(areas, i)
+		}
+	}
+	for _, areaID := range areas {
+		if progress != nil {
+			progress(areaID)
+		}
+		area := Detached(Areas[areaID])
+		for i := uint32(1); i <= area.GetCount(); i++ {
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+			m, err := area.GetMsg(i)
+			if err != nil || m == nil {
+				continue
+			}
+			if line, ok := matchMessage(re, m); ok {
+				hit(SearchHit{AreaID: areaID, MsgNum: i, From: m.From, Subject: m.Subject, Line: line})
+			}
+		}
+	}
+	return nil
+}
+
+func matchMessage(re *regexp.Regexp, m *Message) (string, bool) {
+	for _, h := range []string{m.From, m.To, m.Subject} {
+		if re.MatchString(h) {
+			return h, true
+		}
+	}
+	for _, l := range strings.Split(m.Body, "\x0d") {
+		if len(l) > 0 && l[0] == '\x01' {
+			continue
+		}
+		if re.MatchString(l) {
+			return strings.TrimSpace(l), true
+		}
+	}
+	return "", false
+}
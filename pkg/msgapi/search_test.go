@@ -0,0 +1,66 @@
+package msgapi
+
+import (
+	"github.com/askovpen/gossiped/pkg/config"
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSearch(t *testing.T) {
+	One := &MSG{AreaPath: "../../testdata/searchtest/one", AreaName: "fido.one", AreaType: EchoAreaTypeEcho}
+	Two := &MSG{AreaPath: "../../testdata/searchtest/two", AreaName: "fido.two", AreaType: EchoAreaTypeEcho}
+	Local := &MSG{AreaPath: "../../testdata/searchtest/local", AreaName: "local", AreaType: EchoAreaTypeLocal}
+	Areas = Areas[:0]
+	Areas = append(Areas, One, Two, Local)
+	config.Config.Chrs.Default = "CP866 2"
+	for i, body := range []string{"Hello world", "Nothing here", "HELLO again", "Привет, мир"} {
+		m := &Message{
+			From:        "SysOp",
+			To:          "All",
+			Subject:     "Test",
+			FromAddr:    types.AddrFromNum(2, 5020, 9696, 1),
+			ToAddr:      &types.FidoAddr{},
+			DateWritten: time.Now(),
+			DateArrived: time.Now(),
+			Body:        "\x01MSGID: 2:5020/9696.1 hello\x0d" + body + "\x0d * Origin: test (2:5020/9696.1)\x0d",
+			Kludges:     make(map[string]string),
+		}
+		Areas[i%3].SaveMsg(m)
+	}
+	g := Goblin(t)
+	g.Describe("Check search", func() {
+		search := func(q *SearchQuery) (hits []SearchHit) {
+			err := SearchMessages(q, nil, func(h SearchHit) { hits = append(hits, h) }, nil)
+			g.Assert(err).Equal(nil)
+			return
+		}
+		g.It("area mask", func() {
+			g.Assert(AreasByMask("fido.*")).Equal([]int{0, 1})
+		})
+		g.It("search string", func() {
+			hits := search(&SearchQuery{Text: "hello"})
+			g.Assert(len(hits)).Equal(2)
+			g.Assert(hits[0].AreaID).Equal(0)
+			g.Assert(hits[0].Line).Equal("Hello world")
+			g.Assert(hits[1].AreaID).Equal(2)
+		})
+		g.It("search case sensitive in areas", func() {
+			hits := search(&SearchQuery{Text: "Hello", CaseSensitive: true, Areas: []int{0, 2}})
+			g.Assert(len(hits)).Equal(1)
+		})
+		g.It("search regexp", func() {
+			hits := search(&SearchQuery{Text: "^(Nothing|Привет)", Regexp: true})
+			g.Assert(len(hits)).Equal(2)
+			g.Assert(hits[0].MsgNum).Equal(uint32(2))
+			g.Assert(hits[0].Line).Equal("Привет, мир")
+		})
+		g.It("bad regexp", func() {
+			g.Assert(SearchMessages(&SearchQuery{Text: "(", Regexp: true}, nil, nil, nil) == nil).IsFalse()
+		})
+	})
+	config.Config.Chrs.Default = ""
+	os.RemoveAll("../../testdata/searchtest")
+}
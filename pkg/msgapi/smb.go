@@ -0,0 +1,777 @@
+package msgapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/askovpen/gossiped/pkg/types"
+	"github.com/askovpen/gossiped/pkg/utils"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// SMB struct
+type SMB struct {
+	AreaPath       string
+	AreaName       string
+	AreaType       EchoAreaType
+	Chrs           string
+	indexStructure []smbIdx
+	messages       []MessageListItem
+	status         smbStatus
+}
+
+type smbStatus struct {
+	ID                                                [4]byte
+	Version, Length                                   uint16
+	LastMsg, TotalMsgs, HeaderOffset, MaxCrcs, MaxMsg uint32
+	MaxAge, Attr                                      uint16
+}
+
+type smbIdx struct {
+	To, From, Subj, Attr uint16
+	Offset, Number, Time uint32
+}
+
+type smbHdr struct {
+	ID                                                [4]byte
+	Type, Version, Length, Attr                       uint16
+	AuxAttr, NetAttr                                  uint32
+	WrittenTime                                       uint32
+	WrittenZone                                       uint16
+	ImportedTime                                      uint32
+	ImportedZone                                      uint16
+	Number, ThreadBack, ThreadNext, ThreadFirst       uint32
+	DeliveryAttempts, Votes                           uint16
+	ThreadID, TimesDownloaded, LastDownloaded, Offset uint32
+	TotalDfields                                      uint16
+}
+
+type smbDfield struct {
+	Type           uint16
+	Offset, Length uint32
+}
+
+// SMBAttrs SMB message attributes
+type SMBAttrs uint16
+
+// message attributes
+const (
+	SMBPRIVATE   SMBAttrs = 0x0001
+	SMBREAD      SMBAttrs = 0x0002
+	SMBPERMANENT SMBAttrs = 0x0004
+	SMBLOCKED    SMBAttrs = 0x0008
+	SMBDELETE    SMBAttrs = 0x0010
+	SMBANONYMOUS SMBAttrs = 0x0020
+	SMBKILLREAD  SMBAttrs = 0x0040
+	SMBMODERATED SMBAttrs = 0x0080
+	SMBVALIDATED SMBAttrs = 0x0100
+	SMBREPLIED   SMBAttrs = 0x0200
+	SMBNOREPLY   SMBAttrs = 0x0400
+)
+
+// SMBNetAttrs SMB network attributes
+type SMBNetAttrs uint32
+
+// network attributes
+const (
+	SMBLOCAL     SMBNetAttrs = 0x0001
+	SMBINTRANSIT SMBNetAttrs = 0x0002
+	SMBSENT      SMBNetAttrs = 0x0004
+	SMBKILLSENT  SMBNetAttrs = 0x0008
+	SMBARCSENT   SMBNetAttrs = 0x0010
+	SMBHOLD      SMBNetAttrs = 0x0020
+	SMBCRASH     SMBNetAttrs = 0x0040
+	SMBIMMEDIATE SMBNetAttrs = 0x0080
+	SMBDIRECT    SMBNetAttrs = 0x0100
+	SMBGATE      SMBNetAttrs = 0x0200
+	SMBORPHAN    SMBNetAttrs = 0x0400
+	SMBFPU       SMBNetAttrs = 0x0800
+)
+
+// header fields
+const (
+	smbSender           = 0x00
+	smbSenderNetType    = 0x02
+	smbSenderNetAddr    = 0x03
+	smbRecipient        = 0x30
+	smbRecipientNetType = 0x32
+	smbRecipientNetAddr = 0x33
+	smbSubject          = 0x60
+	smbFidoCtrl         = 0xa0
+	smbFidoArea         = 0xa1
+	smbFidoSeenBy       = 0xa2
+	smbFidoPath         = 0xa3
+	smbFidoMsgID        = 0xa4
+	smbFidoReplyID      = 0xa5
+	smbFidoPID          = 0xa6
+	smbFidoFlags        = 0xa7
+	smbFidoTID          = 0xa8
+	smbTextBody         = 0x00
+	smbTextTail         = 0x02
+	smbNetFido          = 2
+	smbXlatNone         = 0
+	smbBlockLen         = 256
+	smbHdrLen           = 70
+	smbStatusLen        = 32
+	smbIdxLen           = 20
+	smbUSZone           = 0x4000
+	smbDaylight         = 0x8000
+	smbWesternZone      = 0x1000
+	smbEasternZone      = 0x2000
+)
+
+func (s *SMB) getAttrs(a uint16, na uint32) (attrs []string) {
+	datr := []string{
+		"Pvt", "Rcv", "Prm", "Lck",
+		"[red]Del[silver]", "Ano", "K/r", "Mod",
+		"Val", "Rpl", "", "",
+		"", "", "", "",
+	}
+	natr := []string{
+		"Loc", "Trs", "Snt", "K/s",
+		"A/s", "Hld", "Cra", "Imm",
+		"Dir", "", "Orp", "",
+	}
+	for i := 0; a > 0; i++ {
+		if a&1 > 0 && datr[i] != "" {
+			attrs = append(attrs, datr[i])
+		}
+		a >>= 1
+	}
+	for i := 0; na > 0 && i < len(natr); i++ {
+		if na&1 > 0 && natr[i] != "" {
+			attrs = append(attrs, natr[i])
+		}
+		na >>= 1
+	}
+	return
+}
+
+func crc16(data []byte) uint16 {
+	crc := uint16(0)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 > 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func smbNameCRC(name string) uint16 {
+	b := []byte(name)
+	for i := range b {
+		b[i] = tolower(b[i])
+	}
+	return crc16(b)
+}
+
+func smbSubjectCRC(subj string) uint16 {
+	for len(subj) > 2 && strings.EqualFold(subj[0:3], "re:") {
+		subj = strings.TrimLeft(subj[3:], " ")
+	}
+	return smbNameCRC(subj)
+}
+
+func smbZoneOffset(z uint16) int {
+	off := int(int16(z))
+	if z&(smbUSZone|smbWesternZone) > 0 {
+		off = -int(z & 0xfff)
+	} else if z&smbEasternZone > 0 {
+		off = int(z & 0xfff)
+	}
+	if z&smbDaylight > 0 {
+		off += 60
+	}
+	return off
+}
+
+func smbZone(t time.Time) uint16 {
+	_, off := t.Zone()
+	off /= 60
+	if off < 0 {
+		return smbWesternZone | uint16(-off)
+	} else if off > 0 {
+		return smbEasternZone | uint16(off)
+	}
+	return 0
+}
+
+func smbTime(t uint32, z uint16) time.Time {
+	return time.Unix(int64(t), 0).In(time.FixedZone("", smbZoneOffset(z)*60))
+}
+
+func smbAddr(val []byte) *types.FidoAddr {
+	if len(val) == 8 {
+		return types.AddrFromNum(
+			binary.LittleEndian.Uint16(val[0:]),
+			binary.LittleEndian.Uint16(val[2:]),
+			binary.LittleEndian.Uint16(val[4:]),
+			binary.LittleEndian.Uint16(val[6:]))
+	}
+	return types.AddrFromString(strings.Trim(string(val), "\x00"))
+}
+
+func smbPackAddr(a *types.FidoAddr) []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint16(buf[0:], a.GetZone())
+	binary.LittleEndian.PutUint16(buf[2:], a.GetNet())
+	binary.LittleEndian.PutUint16(buf[4:], a.GetNode())
+	binary.LittleEndian.PutUint16(buf[6:], a.GetPoint())
+	return buf
+}
+
+func (s *SMB) readHeader(f *os.File, offset uint32) (smbHdr, []smbDfield, []byte, error) {
+	var hdr smbHdr
+	_, err := f.Seek(int64(offset), 0)
+	if err != nil {
+		return hdr, nil, nil, err
+	}
+	header := make([]byte, smbHdrLen)
+	_, err = f.Read(header)
+	if err != nil {
+		return hdr, nil, nil, err
+	}
+	if err = utils.ReadStructFromBuffer(bytes.NewBuffer(header), &hdr); err != nil {
+		return hdr, nil, nil, err
+	}
+	if string(hdr.ID[:]) != "SHD\x1a" {
+		return hdr, nil, nil, errors.New("wrong message signature")
+	}
+	if int(hdr.Length) < smbHdrLen+10*int(hdr.TotalDfields) {
+		return hdr, nil, nil, errors.New("wrong message header length")
+	}
+	rest := make([]byte, int(hdr.Length)-smbHdrLen)
+	f.Read(rest)
+	restb := bytes.NewBuffer(rest)
+	dfields := make([]smbDfield, hdr.TotalDfields)
+	for i := range dfields {
+		if err = utils.ReadStructFromBuffer(restb, &dfields[i]); err != nil {
+			return hdr, nil, nil, err
+		}
+	}
+	return hdr, dfields, restb.Bytes(), nil
+}
+
+func (s *SMB) getOffsetByNum(num uint32) uint32 {
+	for i, is := range s.indexStructure {
+		if is.Number == num {
+			return uint32(i) + 1
+		}
+	}
+	return 0
+}
+
+// GetMsg return msg
+func (s *SMB) GetMsg(position uint32) (*Message, error) {
+	if len(s.indexStructure) == 0 {
+		return nil, nil
+	}
+	if position == 0 {
+		position = 1
+	}
+	f, err := os.Open(s.AreaPath + ".shd")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hdr, dfields, hfields, err := s.readHeader(f, s.indexStructure[position-1].Offset)
+	if err != nil {
+		return nil, err
+	}
+	rm := &Message{Area: s.AreaName,
+		MsgNum:      position,
+		MaxNum:      uint32(len(s.indexStructure)),
+		DateWritten: smbTime(hdr.WrittenTime, hdr.WrittenZone),
+		DateArrived: smbTime(hdr.ImportedTime, hdr.ImportedZone),
+		Attrs:       s.getAttrs(hdr.Attr, hdr.NetAttr),
+	}
+	if hdr.ThreadBack > 0 {
+		rm.ReplyTo = s.getOffsetByNum(hdr.ThreadBack)
+	}
+	if hdr.ThreadFirst > 0 {
+		rm.Replies = append(rm.Replies, s.getOffsetByNum(hdr.ThreadFirst))
+	}
+	afterBody := ""
+	hb := bytes.NewBuffer(hfields)
+	for {
+		var hfType, hfLen uint16
+		if err = binary.Read(hb, binary.LittleEndian, &hfType); err != nil {
+			break
+		}
+		if err = binary.Read(hb, binary.LittleEndian, &hfLen); err != nil {
+			break
+		}
+		val := hb.Next(int(hfLen))
+		sval := strings.TrimRight(string(val), "\x00")
+		switch hfType {
+		case smbSender:
+			rm.From = sval
+		case smbSenderNetAddr:
+			rm.FromAddr = smbAddr(val)
+		case smbRecipient:
+			rm.To = sval
+		case smbRecipientNetAddr:
+			if s.AreaType != EchoAreaTypeLocal && s.AreaType != EchoAreaTypeEcho {
+				rm.ToAddr = smbAddr(val)
+			}
+		case smbSubject:
+			rm.Subject = sval
+		case smbFidoMsgID:
+			rm.Body += "\x01MSGID: " + sval + "\x0d"
+		case smbFidoReplyID:
+			rm.Body += "\x01REPLY: " + sval + "\x0d"
+		case smbFidoPID:
+			rm.Body += "\x01PID: " + sval + "\x0d"
+		case smbFidoTID:
+			rm.Body += "\x01TID: " + sval + "\x0d"
+		case smbFidoFlags:
+			rm.Body += "\x01FLAGS " + sval + "\x0d"
+		case smbFidoCtrl:
+			rm.Body += "\x01" + sval + "\x0d"
+		case smbFidoSeenBy:
+			afterBody += "SEEN-BY: " + sval + "\x0d"
+		case smbFidoPath:
+			afterBody += "\x01PATH: " + sval + "\x0d"
+		}
+	}
+	fSdt, err := os.Open(s.AreaPath + ".sdt")
+	if err != nil {
+		return nil, err
+	}
+	defer fSdt.Close()
+	for _, df := range dfields {
+		if df.Type != smbTextBody && df.Type != smbTextTail {
+			continue
+		}
+		txt, err := s.readText(fSdt, hdr.Offset+df.Offset, df.Length)
+		if err != nil {
+			rm.Corrupted = true
+			continue
+		}
+		rm.Body += txt
+	}
+	rm.Body += afterBody
+	err = rm.ParseRaw()
+	if err != nil {
+		return nil, err
+	}
+	return rm, nil
+}
+
+func (s *SMB) readText(f *os.File, offset uint32, length uint32) (string, error) {
+	_, err := f.Seek(int64(offset), 0)
+	if err != nil {
+		return "", err
+	}
+	data := make([]byte, length)
+	f.Read(data)
+	for len(data) > 1 {
+		xlat := binary.LittleEndian.Uint16(data)
+		data = data[2:]
+		if xlat == smbXlatNone {
+			return strings.TrimRight(strings.Replace(string(data), "\x0a", "", -1), "\x00"), nil
+		}
+	}
+	return "", errors.New("compressed text not supported")
+}
+
+func (s *SMB) readStatus() error {
+	f, err := os.Open(s.AreaPath + ".shd")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// only status header is read, headers of messages follow it
+	b := make([]byte, smbStatusLen)
+	if _, err = io.ReadFull(f, b); err != nil {
+		return err
+	}
+	if err = utils.ReadStructFromBuffer(bytes.NewBuffer(b), &s.status); err != nil {
+		return err
+	}
+	if string(s.status.ID[:]) != "SMB\x1a" {
+		return errors.New("wrong SMB signature")
+	}
+	return nil
+}
+
+func (s *SMB) readSID() {
+	if len(s.indexStructure) > 0 {
+		return
+	}
+	file, err := os.Open(s.AreaPath + ".sid")
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	part := make([]byte, 20*1024)
+	for {
+		count, err := reader.Read(part)
+		if err != nil {
+			break
+		}
+		partb := bytes.NewBuffer(part[:count])
+		for {
+			var idx smbIdx
+			if err = utils.ReadStructFromBuffer(partb, &idx); err != nil {
+				break
+			}
+			if idx.Number != 0 && idx.Attr&uint16(SMBDELETE) == 0 {
+				s.indexStructure = append(s.indexStructure, idx)
+			}
+		}
+	}
+}
+
+// GetLast return last message
+//
+// Synchronet keeps message pointers in the user database, so lastread is
+// stored next to the base in a .glr file
+func (s *SMB) GetLast() uint32 {
+	s.readSID()
+	if len(s.indexStructure) == 0 {
+		return 0
+	}
+	file, err := os.Open(s.AreaPath + ".glr")
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+	var ret uint32
+	if err = binary.Read(file, binary.LittleEndian, &ret); err != nil {
+		return 0
+	}
+	for i, is := range s.indexStructure {
+		if ret == is.Number {
+			return uint32(i + 1)
+		}
+	}
+	if ret != 0 {
+		return uint32(len(s.indexStructure))
+	}
+	return 0
+}
+
+// SetLast set last message
+func (s *SMB) SetLast(l uint32) {
+	if l == 0 {
+		l = 1
+	}
+	s.readSID()
+	if int(l) > len(s.indexStructure) {
+		return
+	}
+	buf := new(bytes.Buffer)
+	err := binary.Write(buf, binary.LittleEndian, s.indexStructure[l-1].Number)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	err = ioutil.WriteFile(s.AreaPath+".glr", buf.Bytes(), 0644)
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// GetCount return count messages
+func (s *SMB) GetCount() uint32 {
+	s.readSID()
+	return uint32(len(s.indexStructure))
+}
+
+// GetMsgType return msg base type
+func (s *SMB) GetMsgType() EchoAreaMsgType {
+	return EchoAreaMsgTypeSMB
+}
+
+// GetType return area type
+func (s *SMB) GetType() EchoAreaType {
+	return s.AreaType
+}
+
+// Init init
+func (s *SMB) Init() {
+}
+
+// GetName return area name
+func (s *SMB) GetName() string {
+	return s.AreaName
+}
+
+// SetChrs set charset
+func (s *SMB) SetChrs(c string) {
+	s.Chrs = c
+}
+
+// GetChrs get charset
+func (s *SMB) GetChrs() string {
+	return s.Chrs
+}
+
+func packSMBField(b *bytes.Buffer, t uint16, data []byte) {
+	binary.Write(b, binary.LittleEndian, t)
+	binary.Write(b, binary.LittleEndian, uint16(len(data)))
+	b.Write(data)
+}
+
+func (s *SMB) packFields(tm *Message) []byte {
+	b := new(bytes.Buffer)
+	packSMBField(b, smbSender, []byte(tm.From))
+	if tm.FromAddr != nil && tm.FromAddr.GetZone() > 0 {
+		packSMBField(b, smbSenderNetType, []byte{smbNetFido, 0})
+		packSMBField(b, smbSenderNetAddr, smbPackAddr(tm.FromAddr))
+	}
+	packSMBField(b, smbRecipient, []byte(tm.To))
+	if s.AreaType == EchoAreaTypeNetmail && tm.ToAddr != nil {
+		packSMBField(b, smbRecipientNetType, []byte{smbNetFido, 0})
+		packSMBField(b, smbRecipientNetAddr, smbPackAddr(tm.ToAddr))
+	}
+	packSMBField(b, smbSubject, []byte(tm.Subject))
+	for kl, v := range tm.Kludges {
+		switch kl {
+		case "MSGID:":
+			packSMBField(b, smbFidoMsgID, []byte(v))
+		case "REPLY:":
+			packSMBField(b, smbFidoReplyID, []byte(v))
+		case "PID:":
+			packSMBField(b, smbFidoPID, []byte(v))
+		case "TID:":
+			packSMBField(b, smbFidoTID, []byte(v))
+		case "FLAGS":
+			packSMBField(b, smbFidoFlags, []byte(v))
+		default:
+			packSMBField(b, smbFidoCtrl, []byte(kl+" "+v))
+		}
+	}
+	return b.Bytes()
+}
+
+func smbAlloc(fn string, from int64, blocks int64, size int) error {
+	f, err := os.OpenFile(fn, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rec := make([]byte, size)
+	rec[0] = 1
+	_, err = f.Seek(from*int64(size), 0)
+	if err != nil {
+		return err
+	}
+	for i := int64(0); i < blocks; i++ {
+		if _, err = f.Write(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func smbBlocks(l int64) int64 {
+	return (l + smbBlockLen - 1) / smbBlockLen
+}
+
+// SaveMsg save message
+func (s *SMB) SaveMsg(tm *Message) (err error) {
+	key, err := checkDupe(s.AreaName, s.AreaType, tm)
+	if err != nil {
+		return err
+	}
+	defer addDupe(s.AreaType, key, &err)
+	unlock, err := lockFlag(s.AreaPath + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	s.indexStructure = nil
+	s.readSID()
+	if err := s.readStatus(); err != nil {
+		copy(s.status.ID[:], "SMB\x1a")
+		s.status.Version = 0x0300
+		s.status.Length = smbStatusLen
+		s.status.HeaderOffset = smbStatusLen
+		s.status.LastMsg = 0
+		s.status.TotalMsgs = 0
+	}
+	tm.Encode()
+	text := new(bytes.Buffer)
+	binary.Write(text, binary.LittleEndian, uint16(smbXlatNone))
+	// Synchronet stores text with CRLF line endings
+	text.WriteString(strings.Replace(tm.Body, "\x0d", "\x0d\x0a", -1))
+	fSdt, err := os.OpenFile(s.AreaPath+".sdt", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer fSdt.Close()
+	end, _ := fSdt.Seek(0, 2)
+	dataOffset := smbBlocks(end) * smbBlockLen
+	fSdt.Seek(dataOffset, 0)
+	data := text.Bytes()
+	data = append(data, make([]byte, smbBlocks(int64(len(data)))*smbBlockLen-int64(len(data)))...)
+	if _, err = fSdt.Write(data); err != nil {
+		return err
+	}
+	fSdt.Close()
+	if err = smbAlloc(s.AreaPath+".sda", dataOffset/smbBlockLen, smbBlocks(int64(text.Len())), 2); err != nil {
+		return err
+	}
+	fields := s.packFields(tm)
+	hdr := smbHdr{
+		Version:      s.status.Version,
+		Length:       uint16(smbHdrLen + 10 + len(fields)),
+		WrittenTime:  uint32(tm.DateWritten.Unix()),
+		WrittenZone:  smbZone(tm.DateWritten),
+		ImportedTime: uint32(tm.DateArrived.Unix()),
+		ImportedZone: smbZone(tm.DateArrived),
+		Number:       s.status.LastMsg + 1,
+		Offset:       uint32(dataOffset),
+		TotalDfields: 1,
+	}
+	if tm.HasAttr("Loc") {
+		hdr.NetAttr = uint32(SMBLOCAL)
+	}
+	copy(hdr.ID[:], "SHD\x1a")
+	buf := new(bytes.Buffer)
+	if err = utils.WriteStructToBuffer(buf, &hdr); err != nil {
+		return err
+	}
+	df := smbDfield{Type: smbTextBody, Length: uint32(text.Len())}
+	if err = utils.WriteStructToBuffer(buf, &df); err != nil {
+		return err
+	}
+	buf.Write(fields)
+	fShd, err := os.OpenFile(s.AreaPath+".shd", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer fShd.Close()
+	end, _ = fShd.Seek(0, 2)
+	if end < int64(s.status.HeaderOffset) {
+		end = int64(s.status.HeaderOffset)
+	}
+	hdrBlock := smbBlocks(end - int64(s.status.HeaderOffset))
+	hdrOffset := int64(s.status.HeaderOffset) + hdrBlock*smbBlockLen
+	hb := buf.Bytes()
+	hb = append(hb, make([]byte, smbBlocks(int64(len(hb)))*smbBlockLen-int64(len(hb)))...)
+	fShd.Seek(hdrOffset, 0)
+	if _, err = fShd.Write(hb); err != nil {
+		return err
+	}
+	s.status.LastMsg++
+	s.status.TotalMsgs++
+	buf.Reset()
+	if err = utils.WriteStructToBuffer(buf, &s.status); err != nil {
+		return err
+	}
+	fShd.Seek(0, 0)
+	if _, err = fShd.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	fShd.Close()
+	if err = smbAlloc(s.AreaPath+".sha", hdrBlock, smbBlocks(int64(len(hb))), 1); err != nil {
+		return err
+	}
+	idx := smbIdx{
+		To:     smbNameCRC(tm.To),
+		From:   smbNameCRC(tm.From),
+		Subj:   smbSubjectCRC(tm.Subject),
+		Offset: uint32(hdrOffset),
+		Number: hdr.Number,
+		Time:   hdr.ImportedTime,
+	}
+	buf.Reset()
+	if err = utils.WriteStructToBuffer(buf, &idx); err != nil {
+		return err
+	}
+	if _, err = appendFile(s.AreaPath+".sid", buf.Bytes()); err != nil {
+		return err
+	}
+	s.indexStructure = append(s.indexStructure, idx)
+	return nil
+}
+
+// GetMessages get headers
+func (s *SMB) GetMessages() *[]MessageListItem {
+	if s.GetCount() == 0 || uint32(len(s.messages)) == s.GetCount() {
+		return &s.messages
+	}
+	s.messages = readMessageList(s)
+	return &s.messages
+}
+
+// DelMsg mark msg deleted, deleted messages are skipped in index
+func (s *SMB) DelMsg(l uint32) error {
+	if len(s.indexStructure) == 0 {
+		return errors.New("empty Area")
+	}
+	if l == 0 {
+		l = 1
+	}
+	unlock, err := lockFlag(s.AreaPath + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(s.AreaPath+".shd", os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	hdr, _, _, err := s.readHeader(f, s.indexStructure[l-1].Offset)
+	if err != nil {
+		return err
+	}
+	hdr.Attr |= uint16(SMBDELETE)
+	buf := new(bytes.Buffer)
+	if err = utils.WriteStructToBuffer(buf, &hdr); err != nil {
+		return err
+	}
+	f.Seek(int64(s.indexStructure[l-1].Offset), 0)
+	if _, err = f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	f.Close()
+	idx := s.indexStructure[l-1]
+	idx.Attr |= uint16(SMBDELETE)
+	b, err := ioutil.ReadFile(s.AreaPath + ".sid")
+	if err != nil {
+		return err
+	}
+	pos := -1
+	for i := 0; i+smbIdxLen <= len(b); i += smbIdxLen {
+		if binary.LittleEndian.Uint32(b[i+12:]) == idx.Number {
+			pos = i
+			break
+		}
+	}
+	if pos == -1 {
+		return errors.New("message not found in index")
+	}
+	f, err = os.OpenFile(s.AreaPath+".sid", os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	buf.Reset()
+	if err = utils.WriteStructToBuffer(buf, &idx); err != nil {
+		return err
+	}
+	f.Seek(int64(pos), 0)
+	if _, err = f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	s.indexStructure = append(s.indexStructure[:l-1], s.indexStructure[l:]...)
+	s.messages = nil
+	return nil
+}
@@ -0,0 +1,95 @@
+package msgapi
+
+import (
+	"encoding/binary"
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSMB(t *testing.T) {
+	Area := &SMB{
+		AreaPath: "../../testdata/smbtest",
+		AreaName: "test",
+		AreaType: EchoAreaTypeEcho,
+	}
+	Areas = Areas[:0]
+	Areas = append(Areas, Area)
+	g := Goblin(t)
+	g.Describe("Check SMB read/write", func() {
+		m := &Message{
+			AreaID:      0,
+			From:        "SysOp",
+			To:          "All",
+			Subject:     "Test",
+			FromAddr:    types.AddrFromNum(2, 5020, 9696, 1),
+			DateWritten: time.Now(),
+			DateArrived: time.Now(),
+			Body:        "Test\nBody\n * Origin: test (2:5020/9696.1)",
+			Kludges:     make(map[string]string),
+		}
+		m.MakeBody()
+		g.It("create msg", func() {
+			g.Assert(len(*Area.GetMessages())).Equal(0)
+			g.Assert(Area.SaveMsg(m)).Equal(nil)
+		})
+		g.It("add msg", func() {
+			g.Assert(Area.SaveMsg(m)).Equal(nil)
+		})
+		g.It("check num msgs", func() {
+			g.Assert(Area.GetCount()).Equal(uint32(2))
+			fresh := &SMB{AreaPath: "../../testdata/smbtest", AreaName: "test"}
+			g.Assert(fresh.GetCount()).Equal(uint32(2))
+		})
+		g.It("read msg", func() {
+			nm, err := Area.GetMsg(2)
+			g.Assert(err).Equal(nil)
+			g.Assert(nm.From).Equal("SysOp")
+			g.Assert(nm.To).Equal("All")
+			g.Assert(nm.FromAddr).Equal(types.AddrFromNum(2, 5020, 9696, 1))
+			g.Assert(nm.Subject).Equal("Test")
+			g.Assert(nm.Kludges["MSGID:"]).Equal(m.Kludges["MSGID:"])
+			g.Assert(nm.DateWritten.Unix()).Equal(m.DateWritten.Unix())
+		})
+		g.It("get/set last", func() {
+			Area.SetLast(2)
+			g.Assert(Area.GetLast()).Equal(uint32(2))
+			g.Assert(len(*Area.GetMessages())).Equal(2)
+			Area.SetLast(5)
+			g.Assert(Area.GetLast()).Equal(uint32(2))
+			empty := &SMB{AreaPath: "../../testdata/smbempty", AreaName: "empty"}
+			empty.SetLast(1)
+		})
+		g.It("status header and crlf text", func() {
+			b, err := ioutil.ReadFile("../../testdata/smbtest.shd")
+			g.Assert(err).Equal(nil)
+			g.Assert(binary.LittleEndian.Uint16(b[6:])).Equal(uint16(32))
+			sdt, err := ioutil.ReadFile("../../testdata/smbtest.sdt")
+			g.Assert(err).Equal(nil)
+			g.Assert(strings.Contains(string(sdt), "Test\r\nBody\r\n")).IsTrue()
+			nm, err := Area.GetMsg(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(strings.Contains(nm.Body, "Test\x0dBody\x0d")).IsTrue()
+		})
+		g.It("del msg", func() {
+			g.Assert(Area.SaveMsg(m)).Equal(nil)
+			g.Assert(Area.DelMsg(2)).Equal(nil)
+			g.Assert(Area.GetCount()).Equal(uint32(2))
+			g.Assert(len(*Area.GetMessages())).Equal(2)
+			g.Assert(Area.DelMsg(1)).Equal(nil)
+			g.Assert(Area.GetCount()).Equal(uint32(1))
+			fresh := &SMB{AreaPath: "../../testdata/smbtest", AreaName: "test"}
+			g.Assert(fresh.GetCount()).Equal(uint32(1))
+			g.Assert(fresh.indexStructure[0].Number).Equal(uint32(3))
+		})
+	})
+	files, _ := filepath.Glob("../../testdata/smbtest.*")
+	for _, f := range files {
+		os.Remove(f)
+	}
+}
@@ -0,0 +1,380 @@
+package msgapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/askovpen/gossiped/pkg/utils"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// SquishReport result of Squish maintenance
+type SquishReport struct {
+	Messages, Free uint32
+	Problems       []string
+}
+
+type sqFrame struct {
+	offset uint32
+	hdr    sqdH
+}
+
+const (
+	sqID          = 0xafae4453
+	sqBaseHdrLen  = 256
+	sqFrameHdrLen = 28
+	sqMsgHdrLen   = 266
+	sqFrameNormal = 0
+	sqFrameFree   = 1
+)
+
+func (r *SquishReport) problem(format string, a ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, a...))
+}
+
+// readBase read whole .sqd under lock
+func (s *Squish) readBase(lock *lockedFile, r *SquishReport) ([]byte, sqdS, error) {
+	var sqd sqdS
+	data, err := ioutil.ReadAll(lock)
+	if err != nil {
+		return nil, sqd, err
+	}
+	if len(data) < sqBaseHdrLen {
+		return nil, sqd, fmt.Errorf("%s.sqd: base header too short", s.AreaPath)
+	}
+	if err = utils.ReadStructFromBuffer(bytes.NewBuffer(data[:sqBaseHdrLen]), &sqd); err != nil {
+		return nil, sqd, err
+	}
+	if sqd.Len != sqBaseHdrLen {
+		r.problem("base header length %d, expected %d", sqd.Len, sqBaseHdrLen)
+		sqd.Len = sqBaseHdrLen
+	}
+	if sqd.SzSQHdr != sqFrameHdrLen {
+		r.problem("frame header length %d, expected %d", sqd.SzSQHdr, sqFrameHdrLen)
+		sqd.SzSQHdr = sqFrameHdrLen
+	}
+	return data, sqd, nil
+}
+
+// scanFrames walk .sqd frame by frame, skipping to next frame signature
+// after corrupted headers
+func scanFrames(data []byte, sqd sqdS, r *SquishReport) (frames []sqFrame) {
+	sig := make([]byte, 4)
+	binary.LittleEndian.PutUint32(sig, sqID)
+	end := uint32(len(data))
+	for off := uint32(sqd.Len); off+sqFrameHdrLen <= end; {
+		h, err := readSQDH(bytes.NewBuffer(data[off:min32(off+sqMsgHdrLen, end)]))
+		bad := err != nil || uint64(off)+sqFrameHdrLen+uint64(h.FrameLength) > uint64(end)
+		if !bad && h.FrameType == sqFrameNormal {
+			bad = h.FrameLength < sqMsgHdrLen-sqFrameHdrLen || h.MsgLength > h.FrameLength ||
+				h.CLen > h.MsgLength-(sqMsgHdrLen-sqFrameHdrLen)
+		}
+		if bad {
+			r.problem("corrupted frame header at %d", off)
+			next := bytes.Index(data[off+1:], sig)
+			if next < 0 {
+				break
+			}
+			off += uint32(next) + 1
+			continue
+		}
+		frames = append(frames, sqFrame{off, h})
+		off += sqFrameHdrLen + h.FrameLength
+	}
+	return
+}
+
+func min32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func sqiCRC(h sqdH) uint32 {
+	crc := bufHash32(string(h.To[:]))
+	if h.Attr&uint32(SquishREAD) > 0 {
+		crc |= 0x80000000
+	}
+	return crc
+}
+
+func (s *Squish) readRawSQI() (index []sqiS) {
+	b, err := ioutil.ReadFile(s.AreaPath + ".sqi")
+	if err != nil {
+		return nil
+	}
+	buf := bytes.NewBuffer(b)
+	for {
+		var sqi sqiS
+		if err = utils.ReadStructFromBuffer(buf, &sqi); err != nil {
+			break
+		}
+		index = append(index, sqi)
+	}
+	return
+}
+
+// Check validate Squish frame chains and .sqi against frames of .sqd
+func (s *Squish) Check() (*SquishReport, error) {
+	lock, err := lockFile(s.AreaPath+".sqd", squishLockOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+	r := &SquishReport{}
+	data, sqd, err := s.readBase(lock, r)
+	if err != nil {
+		return nil, err
+	}
+	frames := scanFrames(data, sqd, r)
+	byOffset := make(map[uint32]sqdH)
+	for _, f := range frames {
+		byOffset[f.offset] = f.hdr
+		if f.hdr.FrameType == sqFrameFree {
+			r.Free++
+		} else if f.hdr.FrameType == sqFrameNormal {
+			r.Messages++
+		}
+	}
+	walk := func(name string, first, last uint32, frameType uint16) (chain []uint32) {
+		seen := make(map[uint32]bool)
+		prev := uint32(0)
+		for off := first; off != 0; {
+			h, ok := byOffset[off]
+			if !ok {
+				r.problem("%s chain points to %d, no frame there", name, off)
+				break
+			}
+			if seen[off] {
+				r.problem("%s chain loops at %d", name, off)
+				break
+			}
+			seen[off] = true
+			if h.FrameType != frameType {
+				r.problem("%s chain frame at %d has type %d", name, off, h.FrameType)
+			}
+			if h.PrevFrame != prev {
+				r.problem("%s chain frame at %d links back to %d, expected %d", name, off, h.PrevFrame, prev)
+			}
+			chain = append(chain, off)
+			prev = off
+			off = h.NextFrame
+		}
+		if prev != last {
+			r.problem("%s chain ends at %d, header says %d", name, prev, last)
+		}
+		return
+	}
+	chain := walk("message", sqd.BeginFrame, sqd.LastFrame, sqFrameNormal)
+	if uint32(len(chain)) != r.Messages {
+		r.problem("message chain has %d frames, base has %d", len(chain), r.Messages)
+	}
+	if sqd.NumMsg != r.Messages {
+		r.problem("header counts %d messages, base has %d", sqd.NumMsg, r.Messages)
+	}
+	if free := walk("free", sqd.FreeFrame, sqd.LastFreeFrame, sqFrameFree); uint32(len(free)) != r.Free {
+		r.problem("free chain has %d frames, base has %d", len(free), r.Free)
+	}
+	if sqd.EndFrame != uint32(len(data)) {
+		r.problem("end frame %d, file size %d", sqd.EndFrame, len(data))
+	}
+	index := s.readRawSQI()
+	if len(index) != len(chain) {
+		r.problem("index has %d entries, message chain has %d", len(index), len(chain))
+	}
+	for i, e := range index {
+		h, ok := byOffset[e.Offset]
+		if !ok || h.FrameType != sqFrameNormal {
+			r.problem("index entry %d points to %d, no message there", i+1, e.Offset)
+			continue
+		}
+		if i < len(chain) && chain[i] != e.Offset {
+			r.problem("index entry %d points to %d, message chain has %d", i+1, e.Offset, chain[i])
+		}
+		if h.UMsgID != e.MessageNum {
+			r.problem("index entry %d has number %d, frame has %d", i+1, e.MessageNum, h.UMsgID)
+		}
+		if sqiCRC(h) != e.CRC {
+			r.problem("index entry %d has wrong hash", i+1)
+		}
+	}
+	return r, nil
+}
+
+// RebuildIndex relink frame chains and rebuild .sqi from frames of .sqd,
+// corrupted frames are dropped
+func (s *Squish) RebuildIndex() (*SquishReport, error) {
+	return s.rebuild(false, PackOptions{})
+}
+
+// Pack rewrite Squish area without free, deleted and purged frames
+func (s *Squish) Pack(opts PackOptions) (stats PackStats, err error) {
+	before := s.GetCount()
+	r, err := s.rebuild(true, opts)
+	if err != nil {
+		return stats, err
+	}
+	stats.Before = before
+	stats.After = r.Messages
+	if before > r.Messages {
+		stats.Purged = before - r.Messages
+	}
+	return stats, nil
+}
+
+func (s *Squish) rebuild(pack bool, opts PackOptions) (*SquishReport, error) {
+	lock, err := lockFile(s.AreaPath+".sqd", squishLockOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+	r := &SquishReport{}
+	data, sqd, err := s.readBase(lock, r)
+	if err != nil {
+		return nil, err
+	}
+	frames := scanFrames(data, sqd, r)
+	// messages are frames linked in chain or listed in index, other
+	// message frames are left over from deletes and become free
+	live := make(map[uint32]bool)
+	byOffset := make(map[uint32]sqdH)
+	for _, f := range frames {
+		byOffset[f.offset] = f.hdr
+	}
+	for off := sqd.BeginFrame; off != 0 && !live[off]; off = byOffset[off].NextFrame {
+		if h, ok := byOffset[off]; !ok || h.FrameType != sqFrameNormal {
+			break
+		}
+		live[off] = true
+	}
+	for _, e := range s.readRawSQI() {
+		live[e.Offset] = true
+	}
+	var normal, free []sqFrame
+	for _, f := range frames {
+		if f.hdr.FrameType == sqFrameNormal && live[f.offset] {
+			normal = append(normal, f)
+		} else if f.hdr.FrameType == sqFrameNormal {
+			r.problem("orphan message frame at %d freed", f.offset)
+			f.hdr.FrameType = sqFrameFree
+			free = append(free, f)
+		} else if f.hdr.FrameType == sqFrameFree {
+			free = append(free, f)
+		}
+	}
+	if pack {
+		free = nil
+	}
+	sort.SliceStable(normal, func(i, j int) bool { return normal[i].hdr.UMsgID < normal[j].hdr.UMsgID })
+	if pack && opts.MaxAge > 0 {
+		kept := normal[:0]
+		for _, f := range normal {
+			if time.Since(getTime(f.hdr.DateWritten)) <= opts.MaxAge {
+				kept = append(kept, f)
+			}
+		}
+		normal = kept
+	}
+	if pack && opts.MaxMsgs > 0 && uint32(len(normal)) > opts.MaxMsgs {
+		normal = normal[uint32(len(normal))-opts.MaxMsgs:]
+	}
+	offsets := make([]uint32, len(normal))
+	out := data
+	if pack {
+		out = append([]byte{}, data[:sqd.Len]...)
+		off := uint32(sqd.Len)
+		for i, f := range normal {
+			offsets[i] = off
+			off += sqFrameHdrLen + f.hdr.MsgLength
+		}
+	} else {
+		for i, f := range normal {
+			offsets[i] = f.offset
+		}
+	}
+	link := func(offs []uint32, i int, h *sqdH) {
+		h.PrevFrame, h.NextFrame = 0, 0
+		if i > 0 {
+			h.PrevFrame = offs[i-1]
+		}
+		if i < len(offs)-1 {
+			h.NextFrame = offs[i+1]
+		}
+	}
+	var index []sqiS
+	uid := sqd.UID
+	for i, f := range normal {
+		h := f.hdr
+		link(offsets, i, &h)
+		buf := new(bytes.Buffer)
+		if pack {
+			h.FrameLength = h.MsgLength
+			if err = utils.WriteStructToBuffer(buf, &h); err != nil {
+				return nil, err
+			}
+			out = append(out, buf.Bytes()...)
+			out = append(out, data[f.offset+sqMsgHdrLen:f.offset+sqFrameHdrLen+f.hdr.MsgLength]...)
+		} else {
+			if err = utils.WriteStructToBuffer(buf, &h); err != nil {
+				return nil, err
+			}
+			copy(out[f.offset:], buf.Bytes())
+		}
+		index = append(index, sqiS{offsets[i], h.UMsgID, sqiCRC(h)})
+		if h.UMsgID >= uid {
+			uid = h.UMsgID + 1
+		}
+	}
+	freeOffsets := make([]uint32, len(free))
+	for i, f := range free {
+		freeOffsets[i] = f.offset
+	}
+	for i, f := range free {
+		h := f.hdr
+		link(freeOffsets, i, &h)
+		buf := new(bytes.Buffer)
+		if err = utils.WriteStructToBuffer(buf, &h); err != nil {
+			return nil, err
+		}
+		copy(out[f.offset:], buf.Bytes()[:sqFrameHdrLen])
+	}
+	sqd.NumMsg = uint32(len(normal))
+	sqd.HighMsg = sqd.NumMsg
+	sqd.UID = uid
+	sqd.BeginFrame, sqd.LastFrame, sqd.FreeFrame, sqd.LastFreeFrame = 0, 0, 0, 0
+	if len(offsets) > 0 {
+		sqd.BeginFrame, sqd.LastFrame = offsets[0], offsets[len(offsets)-1]
+	}
+	if len(freeOffsets) > 0 {
+		sqd.FreeFrame, sqd.LastFreeFrame = freeOffsets[0], freeOffsets[len(freeOffsets)-1]
+	}
+	sqd.EndFrame = uint32(len(out))
+	buf := new(bytes.Buffer)
+	if err = utils.WriteStructToBuffer(buf, &sqd); err != nil {
+		return nil, err
+	}
+	copy(out, buf.Bytes())
+	if err = lock.Truncate(int64(len(out))); err != nil {
+		return nil, err
+	}
+	if _, err = lock.WriteAt(out, 0); err != nil {
+		return nil, err
+	}
+	buf.Reset()
+	for _, e := range index {
+		utils.WriteStructToBuffer(buf, &e)
+	}
+	if err = ioutil.WriteFile(s.AreaPath+".sqi", buf.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	s.indexStructure = nil
+	s.messages = nil
+	forgetThread(s)
+	s.readSQI()
+	r.Messages = uint32(len(normal))
+	r.Free = uint32(len(free))
+	return r, nil
+}
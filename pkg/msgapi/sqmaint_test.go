@@ -0,0 +1,88 @@
+package msgapi
+
+import (
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSquishMaint(t *testing.T) {
+	Area := &Squish{AreaPath: "../../testdata/sqmainttest", AreaName: "test", AreaType: EchoAreaTypeEcho}
+	Areas = Areas[:0]
+	Areas = append(Areas, Area)
+	for i := 1; i <= 4; i++ {
+		Area.SaveMsg(&Message{
+			From:        "SysOp",
+			To:          "All",
+			Subject:     strconv.Itoa(i),
+			FromAddr:    types.AddrFromNum(2, 5020, 9696, 1),
+			ToAddr:      &types.FidoAddr{},
+			DateWritten: time.Now(),
+			DateArrived: time.Now(),
+			Body:        "Test " + strconv.Itoa(i) + "\x0d * Origin: test (2:5020/9696.1)\x0d",
+			Kludges:     make(map[string]string),
+		})
+	}
+	subject := func(n uint32) string {
+		m, err := Area.GetMsg(n)
+		if err != nil || m == nil {
+			return ""
+		}
+		return m.Subject
+	}
+	g := Goblin(t)
+	g.Describe("Check Squish maintenance", func() {
+		g.It("check clean base", func() {
+			r, err := Area.Check()
+			g.Assert(err).Equal(nil)
+			g.Assert(len(r.Problems)).Equal(0)
+			g.Assert(r.Messages).Equal(uint32(4))
+		})
+		g.It("delete frees frame", func() {
+			g.Assert(Area.DelMsg(1)).Equal(nil)
+			r, err := Area.Check()
+			g.Assert(err).Equal(nil)
+			g.Assert(r.Problems == nil).IsTrue()
+			g.Assert(r.Messages).Equal(uint32(3))
+			g.Assert(r.Free).Equal(uint32(1))
+		})
+		g.It("rebuild index", func() {
+			os.Remove(Area.AreaPath + ".sqi")
+			r, _ := Area.Check()
+			g.Assert(len(r.Problems) > 0).IsTrue()
+			r, err := Area.RebuildIndex()
+			g.Assert(err).Equal(nil)
+			g.Assert(r.Messages).Equal(uint32(3))
+			r, _ = Area.Check()
+			g.Assert(r.Problems == nil).IsTrue()
+			g.Assert(Area.GetCount()).Equal(uint32(3))
+			g.Assert(subject(1)).Equal("2")
+		})
+		g.It("drop corrupted frame and pack", func() {
+			f, _ := os.OpenFile(Area.AreaPath+".sqd", os.O_RDWR, 0644)
+			f.WriteAt([]byte("XXXX"), int64(Area.indexStructure[1].Offset))
+			f.Close()
+			r, _ := Area.Check()
+			g.Assert(len(r.Problems) > 0).IsTrue()
+			fi, _ := os.Stat(Area.AreaPath + ".sqd")
+			stats, err := Area.Pack(PackOptions{})
+			g.Assert(err).Equal(nil)
+			g.Assert(stats.After).Equal(uint32(2))
+			r, _ = Area.Check()
+			g.Assert(r.Problems == nil).IsTrue()
+			g.Assert(r.Free).Equal(uint32(0))
+			pfi, _ := os.Stat(Area.AreaPath + ".sqd")
+			g.Assert(pfi.Size() < fi.Size()).IsTrue()
+			g.Assert(subject(1)).Equal("2")
+			g.Assert(subject(2)).Equal("4")
+			m, _ := Area.GetMsg(2)
+			g.Assert(strings.Contains(m.Body, "Test 4\x0d * Origin: test (2:5020/9696.1)\x0d")).IsTrue()
+		})
+	})
+	os.Remove(Area.AreaPath + ".sqd")
+	os.Remove(Area.AreaPath + ".sqi")
+}
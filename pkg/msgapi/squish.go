@@ -17,6 +17,11 @@ import (
 	//"unicode"
 )
 
+// squishLockOffset Squish MsgAPI locks the base by byte 0 of .sqd, there is
+// no lock file: .sql holds lastread pointers and .lck is used by other bases.
+// Squish, SqPack and tossers built on MsgAPI honour this lock
+const squishLockOffset = 0
+
 // SquishAttrs Squish Attributes
 type SquishAttrs uint32
 
@@ -87,25 +92,9 @@ type sqdH struct {
 }
 
 func (s *Squish) getAttrs(a uint32) (attrs []string) {
-	datr := []string{
-		"Pvt", "", "Rcv", "Snt",
-		"", "Trs", "", "K/s",
-		"Loc", "", "", "",
-		"Rrq", "", "Arq", "",
-		"Scn", "", "", "",
-		"", "", "", "",
-		"", "", "", "",
-		"", "", "", "",
-	}
-	i := 0
-	for a > 0 {
-		if a&1 > 0 {
-			if datr[i] != "" {
-				attrs = append(attrs, datr[i])
-			}
-		}
-		i++
-		a >>= 1
+	attrs = getPktAttrs(uint16(a))
+	if SquishAttrs(a)&SquishSCANNED != 0 {
+		attrs = append(attrs, "Scn")
 	}
 	return
 }
@@ -346,7 +335,19 @@ func (s *Squish) SetLast(l uint32) {
 }
 
 // SaveMsg save message
-func (s *Squish) SaveMsg(tm *Message) error {
+func (s *Squish) SaveMsg(tm *Message) (err error) {
+	key, err := checkDupe(s.AreaName, s.AreaType, tm)
+	if err != nil {
+		return err
+	}
+	defer addDupe(s.AreaType, key, &err)
+	lock, err := lockFile(s.AreaPath+".sqd", squishLockOffset)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	s.indexStructure = nil
+	s.readSQI()
 	lastIdx := len(s.indexStructure) - 1
 	if len(s.indexStructure) == 0 {
 		lastIdx = 0
@@ -361,7 +362,7 @@ func (s *Squish) SaveMsg(tm *Message) error {
 	body := kludges + tm.Body + "\x00"
 	sqdh := sqdH{ID: 0xafae4453,
 		NextFrame:   0,
-		Attr:        uint32(SquishLOCAL | SquishSEEN),
+		Attr:        uint32(getPktAttrNum(tm.Attrs)),
 		DateWritten: setTime(tm.DateWritten),
 		DateArrived: setTime(tm.DateArrived),
 		FromZone:    tm.FromAddr.GetZone(),
@@ -371,6 +372,7 @@ func (s *Squish) SaveMsg(tm *Message) error {
 		CLen:        uint32(len(kludges)),
 		MsgLength:   uint32(len(body)) + 266 - 28,
 		FrameLength: uint32(len(body)) + 266 - 28}
+	sqdh.Attr |= uint32(SquishSEEN)
 	if len(s.indexStructure) > 0 {
 		sqdh.PrevFrame = s.indexStructure[lastIdx].Offset
 	}
@@ -389,11 +391,7 @@ func (s *Squish) SaveMsg(tm *Message) error {
 		sqdh.UMsgID = s.indexStructure[lastIdx].MessageNum + 1
 	}
 	sqi := sqiS{CRC: bufHash32(tm.To), MessageNum: sqdh.UMsgID}
-	f, err := os.OpenFile(s.AreaPath+".sqd", os.O_RDWR|os.O_CREATE, 0644)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+	f := lock.File
 	var header []byte
 	var sqd sqdS
 	var headerb *bytes.Buffer
@@ -451,7 +449,6 @@ func (s *Squish) SaveMsg(tm *Message) error {
 	f.Write(buf.Bytes())
 	buf.Reset()
 	f.Write([]byte(body))
-	f.Close()
 	f, err = os.OpenFile(s.AreaPath+".sqi", os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
 		return err
@@ -481,23 +478,10 @@ func (s *Squish) GetChrs() string {
 
 // GetMessages get headers
 func (s *Squish) GetMessages() *[]MessageListItem {
-	if len(s.messages) > 0 || len(s.indexStructure) == 0 {
+	if s.GetCount() == 0 || uint32(len(s.messages)) == s.GetCount() {
 		return &s.messages
 	}
-
-	for i := uint32(0); i < s.GetCount(); i++ {
-		m, err := s.GetMsg(i + 1)
-		if err != nil {
-			continue
-		}
-		s.messages = append(s.messages, MessageListItem{
-			MsgNum:      i + 1,
-			From:        m.From,
-			To:          m.To,
-			Subject:     m.Subject,
-			DateWritten: m.DateWritten,
-		})
-	}
+	s.messages = readMessageList(s)
 	return &s.messages
 }
 
@@ -509,89 +493,98 @@ func (s *Squish) DelMsg(l uint32) error {
 	if l == 0 {
 		l = 1
 	}
-	f, err := os.OpenFile(s.AreaPath+".sqd", os.O_RDWR, 0644)
+	lock, err := lockFile(s.AreaPath+".sqd", squishLockOffset)
 	if err != nil {
 		return err
 	}
-	defer f.Close()
-	f.Seek(int64(s.indexStructure[l-1].Offset), 0)
-	//var header []byte
-	header := make([]byte, 266)
-	f.Read(header)
-	headerb := bytes.NewBuffer(header)
-	sqdh, err := readSQDH(headerb)
+	defer lock.Unlock()
+	f := lock.File
+	header := make([]byte, 256)
+	f.ReadAt(header, 0)
+	var sqd sqdS
+	if err := utils.ReadStructFromBuffer(bytes.NewBuffer(header), &sqd); err != nil {
+		return err
+	}
+	offset := s.indexStructure[l-1].Offset
+	sqdh, err := readFrame(f, offset)
 	if err != nil {
 		return err
 	}
 	prev := sqdh.PrevFrame
 	next := sqdh.NextFrame
-	if l > 1 {
-		f.Seek(int64(s.indexStructure[l-2].Offset), 0)
-		f.Read(header)
-		headerb := bytes.NewBuffer(header)
-		sqdh, err = readSQDH(headerb)
-		if err != nil {
-			return err
-		}
-		sqdh.NextFrame = next
-		err = utils.WriteStructToBuffer(headerb, &sqdh)
-		if err != nil {
+	if prev > 0 {
+		if err = updateFrame(f, prev, func(h *sqdH) { h.NextFrame = next }); err != nil {
 			return err
 		}
-		f.Seek(int64(s.indexStructure[l-2].Offset), 0)
-		f.Write(headerb.Bytes())
+	} else {
+		sqd.BeginFrame = next
 	}
-	if prev > 0 && int(l) < len(s.indexStructure)-1 {
-		f.Seek(int64(s.indexStructure[l].Offset), 0)
-		f.Read(header)
-		headerb := bytes.NewBuffer(header)
-		sqdh, err = readSQDH(headerb)
-		if err != nil {
-			return err
-		}
-		sqdh.PrevFrame = prev
-		err = utils.WriteStructToBuffer(headerb, &sqdh)
-		if err != nil {
+	if next > 0 {
+		if err = updateFrame(f, next, func(h *sqdH) { h.PrevFrame = prev }); err != nil {
 			return err
 		}
-		f.Seek(int64(s.indexStructure[l].Offset), 0)
-		f.Write(headerb.Bytes())
+	} else {
+		sqd.LastFrame = prev
 	}
-	f.Seek(0, 0)
-	var sqd sqdS
-	header = make([]byte, 256)
-	f.Read(header)
-	headerb = bytes.NewBuffer(header)
-	if err := utils.ReadStructFromBuffer(headerb, &sqd); err != nil {
+	// deleted frame goes to the end of free chain
+	sqdh.FrameType = sqFrameFree
+	sqdh.PrevFrame = sqd.LastFreeFrame
+	sqdh.NextFrame = 0
+	if err = writeFrame(f, offset, sqdh); err != nil {
 		return err
 	}
+	if sqd.LastFreeFrame > 0 {
+		if err = updateFrame(f, sqd.LastFreeFrame, func(h *sqdH) { h.NextFrame = offset }); err != nil {
+			return err
+		}
+	} else {
+		sqd.FreeFrame = offset
+	}
+	sqd.LastFreeFrame = offset
 	sqd.NumMsg--
 	sqd.HighMsg--
-	sqd.UID--
-	f.Seek(0, 0)
-	err = utils.WriteStructToBuffer(headerb, &sqd)
-	if err != nil {
+	buf := new(bytes.Buffer)
+	if err = utils.WriteStructToBuffer(buf, &sqd); err != nil {
+		return err
+	}
+	if _, err = f.WriteAt(buf.Bytes(), 0); err != nil {
 		return err
 	}
-	f.Write(headerb.Bytes())
-
-	f.Close()
 	if len(s.messages) > 0 {
 		s.messages = append(s.messages[:l-1], s.messages[l:]...)
 	}
 	s.indexStructure = append(s.indexStructure[:l-1], s.indexStructure[l:]...)
-	f, err = os.OpenFile(s.AreaPath+".sqi", os.O_RDWR, 0644)
-	if err != nil {
-		return err
-	}
-	f.Truncate(0)
-	f.Seek(0, 0)
+	buf.Reset()
 	for _, is := range s.indexStructure {
-		buf := new(bytes.Buffer)
 		utils.WriteStructToBuffer(buf, &is)
-		f.Write(buf.Bytes())
 	}
-	defer f.Close()
+	return ioutil.WriteFile(s.AreaPath+".sqi", buf.Bytes(), 0644)
+}
 
-	return nil
+// readFrame read frame header at offset
+func readFrame(f *os.File, offset uint32) (sqdH, error) {
+	header := make([]byte, 266)
+	if _, err := f.ReadAt(header, int64(offset)); err != nil {
+		return sqdH{}, err
+	}
+	return readSQDH(bytes.NewBuffer(header))
+}
+
+// writeFrame write frame header at offset
+func writeFrame(f *os.File, offset uint32, h sqdH) error {
+	buf := new(bytes.Buffer)
+	if err := utils.WriteStructToBuffer(buf, &h); err != nil {
+		return err
+	}
+	_, err := f.WriteAt(buf.Bytes(), int64(offset))
+	return err
+}
+
+func updateFrame(f *os.File, offset uint32, update func(h *sqdH)) error {
+	h, err := readFrame(f, offset)
+	if err != nil {
+		return err
+	}
+	update(&h)
+	return writeFrame(f, offset, h)
 }
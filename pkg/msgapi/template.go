@@ -0,0 +1,209 @@
+package msgapi
+
+import (
+	"github.com/askovpen/gossiped/pkg/config"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TemplateData message being written and the original one for GoldED template
+type TemplateData struct {
+	Msg     *Message
+	Orig    *Message
+	Forward bool
+	Changed bool
+	Comment bool
+}
+
+// templateNow current time of @CDate and @CTime
+var templateNow = time.Now
+
+var templateLineRE = regexp.MustCompile(`^@([A-Za-z]+)`)
+
+func (d *TemplateData) isReply() bool {
+	return d.Orig != nil && !d.Forward && !d.Changed
+}
+
+// areaName name of area by id, empty if unknown
+func areaName(id int) string {
+	if id < 0 || id >= len(Areas) {
+		return ""
+	}
+	return Areas[id].GetName()
+}
+
+func areaType(id int) EchoAreaType {
+	if id < 0 || id >= len(Areas) {
+		return EchoAreaTypeNone
+	}
+	return Areas[id].GetType()
+}
+
+// condition reports whether template line with conditional prefix is used,
+// ok is false for unknown prefix
+func (d *TemplateData) condition(name string) (use bool, ok bool) {
+	switch strings.ToLower(name) {
+	case "new":
+		return d.Orig == nil, true
+	case "reply":
+		return d.isReply(), true
+	case "quoted":
+		return d.isReply() && !d.Comment, true
+	case "comment":
+		return d.isReply() && d.Comment, true
+	case "moved":
+		return d.isReply() && d.Orig.AreaID != d.Msg.AreaID, true
+	case "forward":
+		return d.Forward && d.Orig != nil, true
+	case "changed":
+		return d.Changed && d.Orig != nil, true
+	case "net":
+		return areaType(d.Msg.AreaID) == EchoAreaTypeNetmail, true
+	case "echo":
+		return areaType(d.Msg.AreaID) == EchoAreaTypeEcho, true
+	case "local":
+		return areaType(d.Msg.AreaID) == EchoAreaTypeLocal, true
+	}
+	return false, false
+}
+
+func firstName(name string) string {
+	if f := strings.Fields(name); len(f) > 0 {
+		return f[0]
+	}
+	return ""
+}
+
+func lastName(name string) string {
+	if f := strings.Fields(name); len(f) > 0 {
+		return f[len(f)-1]
+	}
+	return ""
+}
+
+// tokens values of template tokens
+func (d *TemplateData) tokens() map[string]string {
+	m := d.Msg
+	cname := m.From
+	if cname == "" {
+		cname = config.Config.Username
+	}
+	now := templateNow()
+	t := map[string]string{
+		"pseudo":  m.To,
+		"CName":   cname,
+		"CFName":  firstName(cname),
+		"CLName":  lastName(cname),
+		"CAddr":   config.Config.Address.String(),
+		"CDate":   now.Format("02 Jan 06"),
+		"CTime":   now.Format("15:04:05"),
+		"FName":   m.From,
+		"FFName":  firstName(m.From),
+		"FLName":  lastName(m.From),
+		"FAddr":   m.FromAddr.String(),
+		"TName":   m.To,
+		"TFName":  firstName(m.To),
+		"TLName":  lastName(m.To),
+		"TAddr":   m.ToAddr.String(),
+		"Subject": m.Subject,
+		"Area":    areaName(m.AreaID),
+		"DEcho":   areaName(m.AreaID),
+		"Origin":  config.Config.Origin,
+		"Tagline": config.Config.Tagline,
+	}
+	for _, k := range []string{"OName", "OFName", "OLName", "OAddr", "ODate", "OTime", "OEcho",
+		"DName", "DFName", "DLName", "DAddr"} {
+		t[k] = ""
+	}
+	if om := d.Orig; om != nil {
+		t["OName"] = om.From
+		t["OFName"] = firstName(om.From)
+		t["OLName"] = lastName(om.From)
+		t["OAddr"] = om.FromAddr.String()
+		t["ODate"] = om.DateWritten.Format("02 Jan 06")
+		t["OTime"] = om.DateWritten.Format("15:04:05")
+		t["OEcho"] = areaName(om.AreaID)
+		t["DName"] = om.To
+		t["DFName"] = firstName(om.To)
+		t["DLName"] = lastName(om.To)
+		t["DAddr"] = om.ToAddr.String()
+		t["Subject"] = om.Subject
+	}
+	return t
+}
+
+// tokenReplacer replaces @Token with values, longest names first, case insensitive
+func tokenReplacer(tokens map[string]string) func(string) string {
+	names := make([]string, 0, len(tokens))
+	lower := make(map[string]string, len(tokens))
+	for k, v := range tokens {
+		names = append(names, k)
+		lower[strings.ToLower(k)] = v
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	re := regexp.MustCompile(`(?i)@(@|` + strings.Join(names, "|") + `)`)
+	return func(s string) string {
+		return re.ReplaceAllStringFunc(s, func(t string) string {
+			if t == "@@" {
+				return "@"
+			}
+			return lower[strings.ToLower(t[1:])]
+		})
+	}
+}
+
+// ExecTemplate fills GoldED template lines. Conditional prefixes like @New,
+// @Quoted or @Forward keep the rest of line only for matching messages,
+// @Quote, @Message and @Position lines insert quote, forwarded message and
+// cursor line, @LoadLanguage is accepted and ignored
+func ExecTemplate(tpl []string, d *TemplateData) []string {
+	replace := tokenReplacer(d.tokens())
+	var nm []string
+	for _, l := range tpl {
+		l = strings.TrimRight(l, "\r")
+		use := true
+		for use {
+			name := templateLineRE.FindStringSubmatch(l)
+			if name == nil {
+				break
+			}
+			cond, ok := d.condition(name[1])
+			if !ok {
+				break
+			}
+			use = cond
+			l = l[len(name[0]):]
+		}
+		if !use {
+			continue
+		}
+		name := templateLineRE.FindStringSubmatch(l)
+		if name == nil {
+			nm = append(nm, replace(l))
+			continue
+		}
+		switch strings.ToLower(name[1]) {
+		case "quote":
+			if d.isReply() {
+				nm = append(nm, d.Orig.GetQuote()...)
+			}
+		case "message":
+			if d.Orig != nil && (d.Forward || d.Changed) {
+				nm = append(nm, d.Orig.GetForward()...)
+			}
+		case "position":
+			nm = append(nm, replace(l[len(name[0]):]))
+		case "loadlanguage":
+		default:
+			nm = append(nm, replace(l))
+		}
+	}
+	return nm
+}
@@ -0,0 +1,103 @@
+package msgapi
+
+import (
+	"github.com/askovpen/gossiped/pkg/config"
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplate(t *testing.T) {
+	Areas = Areas[:0]
+	Areas = append(Areas,
+		&MSG{AreaName: "netmail", AreaType: EchoAreaTypeNetmail},
+		&MSG{AreaName: "fido.test", AreaType: EchoAreaTypeEcho},
+		&MSG{AreaName: "fido.other", AreaType: EchoAreaTypeEcho})
+	config.Config.Username = "Alexander Skovpen"
+	config.Config.Address = types.AddrFromNum(2, 5020, 9696, 128)
+	config.Config.Origin = "Just Origin"
+	config.Config.Tagline = "Tagline here"
+	templateNow = func() time.Time { return time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC) }
+	tpl, err := config.ReadTemplate("../../gossiped.tpl")
+	newMsg := func(areaID int, to string) *Message {
+		return &Message{AreaID: areaID, From: config.Config.Username, FromAddr: config.Config.Address, To: to,
+			ToAddr: types.AddrFromNum(2, 5020, 1, 0), Subject: "Re: Hello"}
+	}
+	orig := &Message{
+		AreaID:      1,
+		From:        "Ivan Petrov",
+		FromAddr:    types.AddrFromNum(2, 5020, 1, 0),
+		To:          "All",
+		Subject:     "Hello",
+		DateWritten: time.Date(2021, 5, 30, 10, 15, 0, 0, time.UTC),
+		Body:        "\x01MSGID: 2:5020/1 12345678\x0dHi all!\x0d--- GoldED+\x0d * Origin: Home (2:5020/1)",
+	}
+	g := Goblin(t)
+	g.Describe("Check template", func() {
+		g.It("read shipped template", func() {
+			g.Assert(err == nil).IsTrue()
+			g.Assert(tpl[0]).Equal("@LoadLanguage gedlngus.cfg")
+		})
+		g.It("new message", func() {
+			res := ExecTemplate(tpl, &TemplateData{Msg: newMsg(1, "All")})
+			g.Assert(strings.Join(res, "|")).Equal("Hello All!||||Alexander||")
+		})
+		g.It("reply", func() {
+			res := ExecTemplate(tpl, &TemplateData{Msg: newMsg(1, "Ivan Petrov"), Orig: orig})
+			g.Assert(strings.Join(res, "|")).Equal("Hello Ivan Petrov!||" +
+				"30 May 21 10:15:00, Ivan Petrov wrote to All:||" +
+				" IP> Hi all!||Alexander||")
+		})
+		g.It("moved reply", func() {
+			res := ExecTemplate(tpl, &TemplateData{Msg: newMsg(2, "Ivan Petrov"), Orig: orig})
+			g.Assert(strings.Join(res[:4], "|")).Equal("|*** Answering a msg posted in area fido.test.||Hello Ivan Petrov!")
+		})
+		g.It("comment reply", func() {
+			res := ExecTemplate(tpl, &TemplateData{Msg: newMsg(1, "Ivan Petrov"), Orig: orig, Comment: true})
+			g.Assert(strings.Join(res, "|")).Equal("Hello Ivan Petrov!|| IP> Hi all!||Alexander||")
+		})
+		g.It("forward", func() {
+			res := ExecTemplate(tpl, &TemplateData{Msg: newMsg(0, "Sysop"), Orig: orig, Forward: true})
+			g.Assert(strings.Join(res, "\n")).Equal(strings.Join([]string{
+				strings.Repeat("=", 77),
+				"* Forwarded by Alexander Skovpen (2:5020/9696.128)",
+				"* Area : fido.test",
+				"* From : Ivan Petrov, 2:5020/1 (30 May 21 10:15:00)",
+				"* To   : All",
+				"* Subj : Hello",
+				strings.Repeat("=", 77),
+				"Hi all!",
+				"-+- GoldED+",
+				" + Origin: Home (2:5020/1)",
+				strings.Repeat("=", 77),
+				"",
+				"Hello Sysop!",
+				"",
+				"",
+				"Alexander",
+				"",
+				"",
+			}, "\n"))
+		})
+		g.It("changed", func() {
+			res := ExecTemplate(tpl, &TemplateData{Msg: newMsg(1, "All"), Orig: orig, Changed: true})
+			g.Assert(res[1]).Equal("*** Changed by Alexander Skovpen (2:5020/9696.128), 01 Jun 21 12:30:00.")
+		})
+		g.It("tokens", func() {
+			res := ExecTemplate([]string{
+				"@TName @TFName @TLName @TAddr @FName @FAddr @CLName @Area @DEcho",
+				"@OFName @OLName @DAddr @Origin @Tagline @cfname a@@b mail@host",
+				"@Net@Echo echo only",
+				"@Echo@Local@Net never",
+				"@Net@Position netmail position",
+			}, &TemplateData{Msg: newMsg(0, "Sysop Name"), Orig: orig})
+			g.Assert(res).Equal([]string{
+				"Sysop Name Sysop Name 2:5020/1 Alexander Skovpen 2:5020/9696.128 Skovpen netmail netmail",
+				"Ivan Petrov  Just Origin Tagline here Alexander a@b mail@host",
+				" netmail position",
+			})
+		})
+	})
+}
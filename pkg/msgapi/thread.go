@@ -0,0 +1,156 @@
+package msgapi
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Thread reply tree of area, built from MSGID:/REPLY: kludges of message list
+// with subject fallback
+type Thread struct {
+	count    uint32
+	stamps   []fileStamp
+	parent   map[uint32]uint32
+	children map[uint32][]uint32
+}
+
+var (
+	threads   = make(map[AreaPrimitive]*Thread)
+	threadsMu sync.Mutex
+	subjectRE = regexp.MustCompile(`(?i)^\s*re(\^\d+|\[\d+\])?:\s*`)
+)
+
+func normalizeSubject(s string) (string, bool) {
+	reply := false
+	for subjectRE.MatchString(s) {
+		s = subjectRE.ReplaceAllString(s, "")
+		reply = true
+	}
+	return strings.ToLower(strings.TrimSpace(s)), reply
+}
+
+// BuildThread build reply tree of area from its message list, bodies are not read
+func BuildThread(area AreaPrimitive) *Thread {
+	t := &Thread{
+		count:    area.GetCount(),
+		parent:   make(map[uint32]uint32),
+		children: make(map[uint32][]uint32),
+	}
+	t.stamps, _ = areaStamps(area)
+	msgids := make(map[string]uint32)
+	subjects := make(map[string]uint32)
+	replies := make(map[uint32]string)
+	replySubj := make(map[uint32]string)
+	for _, mi := range *area.GetMessages() {
+		i := mi.MsgNum
+		if mi.MsgID != "" {
+			if _, ok := msgids[mi.MsgID]; !ok {
+				msgids[mi.MsgID] = i
+			}
+		}
+		replies[i] = mi.Reply
+		subj, isReply := normalizeSubject(mi.Subject)
+		if isReply {
+			replySubj[i] = subj
+		} else if _, ok := subjects[subj]; !ok {
+			subjects[subj] = i
+		}
+	}
+	for i := uint32(1); i <= t.count; i++ {
+		if p, ok := msgids[replies[i]]; ok && replies[i] != "" && p != i && !t.isAncestor(i, p) {
+			t.link(p, i)
+		} else if p, ok := subjects[replySubj[i]]; ok && replySubj[i] != "" && p < i && !t.isAncestor(i, p) {
+			t.link(p, i)
+		}
+	}
+	return t
+}
+
+// current reports whether base files and count are unchanged since tree was built
+func (t *Thread) current(area AreaPrimitive) bool {
+	if t.count != area.GetCount() {
+		return false
+	}
+	stamps, err := areaStamps(area)
+	return err == nil && sameStamps(t.stamps, stamps)
+}
+
+func (t *Thread) link(p, n uint32) {
+	t.parent[n] = p
+	t.children[p] = append(t.children[p], n)
+}
+
+func (t *Thread) isAncestor(a, n uint32) bool {
+	for i := uint32(0); n != 0 && i < t.count; i++ {
+		if n == a {
+			return true
+		}
+		n = t.parent[n]
+	}
+	return false
+}
+
+// GetThread return cached reply tree, rebuilt when message base changes
+func GetThread(area AreaPrimitive) *Thread {
+	threadsMu.Lock()
+	defer threadsMu.Unlock()
+	if t, ok := threads[area]; ok && t.current(area) {
+		return t
+	}
+	threads[area] = BuildThread(area)
+	return threads[area]
+}
+
+// CachedThread return reply tree only if it is already built and current, nil otherwise
+func CachedThread(area AreaPrimitive) *Thread {
+	threadsMu.Lock()
+	defer threadsMu.Unlock()
+	if t, ok := threads[area]; ok && t.current(area) {
+		return t
+	}
+	return nil
+}
+
+// forgetThread drop cached reply tree of area
+func forgetThread(area AreaPrimitive) {
+	threadsMu.Lock()
+	delete(threads, area)
+	threadsMu.Unlock()
+}
+
+// Parent return parent message number or 0
+func (t *Thread) Parent(n uint32) uint32 {
+	return t.parent[n]
+}
+
+// Children return replies to message
+func (t *Thread) Children(n uint32) []uint32 {
+	return t.children[n]
+}
+
+// Siblings return other replies to parent of message
+func (t *Thread) Siblings(n uint32) (ret []uint32) {
+	p, ok := t.parent[n]
+	if !ok {
+		return nil
+	}
+	for _, c := range t.children[p] {
+		if c != n {
+			ret = append(ret, c)
+		}
+	}
+	return
+}
+
+// Root return first message of thread
+func (t *Thread) Root(n uint32) uint32 {
+	for i := uint32(0); i < t.count; i++ {
+		p, ok := t.parent[n]
+		if !ok {
+			break
+		}
+		n = p
+	}
+	return n
+}
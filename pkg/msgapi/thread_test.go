@@ -0,0 +1,76 @@
+package msgapi
+
+import (
+	"github.com/askovpen/gossiped/pkg/types"
+	. "github.com/franela/goblin"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestThread(t *testing.T) {
+	Area := &MSG{AreaPath: "../../testdata/threadtest", AreaName: "test", AreaType: EchoAreaTypeEcho}
+	Areas = Areas[:0]
+	Areas = append(Areas, Area)
+	save := func(subj, msgid, reply string) {
+		m := &Message{
+			From:        "SysOp",
+			To:          "All",
+			Subject:     subj,
+			FromAddr:    types.AddrFromNum(2, 5020, 9696, 1),
+			ToAddr:      &types.FidoAddr{},
+			DateWritten: time.Now(),
+			DateArrived: time.Now(),
+			Body:        "Test\x0d * Origin: test (2:5020/9696.1)\x0d",
+			Kludges:     make(map[string]string),
+		}
+		if msgid != "" {
+			m.Kludges["MSGID:"] = msgid
+		}
+		if reply != "" {
+			m.Kludges["REPLY:"] = reply
+		}
+		Area.SaveMsg(m)
+	}
+	for _, tm := range []struct{ subj, msgid, reply string }{
+		{"Hello", "2:5020/9696.1 00000001", ""},
+		{"Re: Hello", "2:5020/9696.1 00000002", "2:5020/9696.1 00000001"},
+		{"Re^2: Hello", "2:5020/9696.1 00000003", "2:5020/9696.1 00000002"},
+		{"RE: hello", "", ""},
+		{"Other", "2:5020/9696.1 00000005", ""},
+		{"Re: Hello", "2:5020/9696.1 00000006", "2:5020/9696.1 00000001"},
+	} {
+		save(tm.subj, tm.msgid, tm.reply)
+	}
+	g := Goblin(t)
+	g.Describe("Check threading", func() {
+		th := GetThread(Area)
+		g.It("parent", func() {
+			g.Assert(th.Parent(1)).Equal(uint32(0))
+			g.Assert(th.Parent(2)).Equal(uint32(1))
+			g.Assert(th.Parent(3)).Equal(uint32(2))
+			g.Assert(th.Parent(4)).Equal(uint32(1))
+			g.Assert(th.Parent(5)).Equal(uint32(0))
+			g.Assert(th.Root(3)).Equal(uint32(1))
+		})
+		g.It("children", func() {
+			g.Assert(th.Children(1)).Equal([]uint32{2, 4, 6})
+			g.Assert(th.Children(2)).Equal([]uint32{3})
+		})
+		g.It("siblings", func() {
+			g.Assert(th.Siblings(4)).Equal([]uint32{2, 6})
+			g.Assert(len(th.Siblings(1))).Equal(0)
+		})
+		g.It("cached until base changes", func() {
+			g.Assert(CachedThread(Area) == th).IsTrue()
+			os.Chtimes(filepath.Join(Area.AreaPath, "5.msg"), time.Now(), time.Now().Add(time.Hour))
+			g.Assert(CachedThread(Area) == nil).IsTrue()
+			th = GetThread(Area)
+			save("Re: Other", "", "2:5020/9696.1 00000005")
+			g.Assert(CachedThread(Area) == nil).IsTrue()
+			g.Assert(GetThread(Area).Children(5)).Equal([]uint32{7})
+		})
+	})
+	os.RemoveAll("../../testdata/threadtest")
+}
@@ -0,0 +1,234 @@
+package msgapi
+
+import (
+	"errors"
+	"github.com/askovpen/gossiped/pkg/config"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TossStats toss counters
+type TossStats struct {
+	Packets, Netmail, Echomail, Bad, Dupes int
+}
+
+type tosser struct {
+	stats  TossStats
+	msgids map[int]map[string]bool
+}
+
+func findAreaByType(t EchoAreaType) int {
+	for i, a := range Areas {
+		if a.GetType() == t && a.GetMsgType() != EchoAreaMsgTypePKT {
+			return i
+		}
+	}
+	return -1
+}
+
+func findAreaByTag(tag string) int {
+	for i, a := range Areas {
+		if strings.EqualFold(a.GetName(), tag) && a.GetMsgType() != EchoAreaMsgTypePKT {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitKludges move kludges from body to Kludges, PATH and Via stay in body
+func splitKludges(m *Message) {
+	var body []string
+	m.Kludges = make(map[string]string)
+	for _, l := range strings.Split(m.Body, "\x0d") {
+		if len(l) < 2 || l[0] != '\x01' || strings.HasPrefix(l, "\x01PATH:") || strings.HasPrefix(l, "\x01Via") {
+			body = append(body, l)
+			continue
+		}
+		kv := strings.SplitN(l[1:], " ", 2)
+		if len(kv) == 1 {
+			kv = append(kv, "")
+		}
+		m.Kludges[kv[0]] = kv[1]
+	}
+	m.Body = strings.Join(body, "\x0d")
+}
+
+func (t *tosser) isDupe(areaID int, m *Message) bool {
+	if Dupes != nil {
+		return Dupes.Check(Areas[areaID].GetName(), m)
+	}
+	msgid := m.Kludges["MSGID:"]
+	if msgid == "" {
+		return false
+	}
+	if _, ok := t.msgids[areaID]; !ok {
+		t.msgids[areaID] = make(map[string]bool)
+		for i := uint32(1); i <= Areas[areaID].GetCount(); i++ {
+			om, err := Areas[areaID].GetMsg(i)
+			if err != nil || om == nil {
+				continue
+			}
+			t.msgids[areaID][om.Kludges["MSGID:"]] = true
+		}
+	}
+	if t.msgids[areaID][msgid] {
+		return true
+	}
+	t.msgids[areaID][msgid] = true
+	return false
+}
+
+func (t *tosser) tossMsg(m *Message) error {
+	areaID := -1
+	tag := m.Area
+	if tag == "" {
+		areaID = findAreaByType(EchoAreaTypeNetmail)
+		if areaID == -1 {
+			return errors.New("netmail area not defined")
+		}
+	} else {
+		areaID = findAreaByTag(tag)
+	}
+	if areaID == -1 {
+		areaID = findAreaByType(EchoAreaTypeBad)
+		if areaID == -1 {
+			return errors.New("bad area not defined, area " + tag + " unknown")
+		}
+		m.Body = "AREA:" + tag + "\x0d" + m.Body
+	}
+	m.AreaID = areaID
+	if err := m.ParseRaw(); err != nil {
+		return err
+	}
+	splitKludges(m)
+	if t.isDupe(areaID, m) {
+		return t.storeDupe(m, tag)
+	}
+	err := t.store(areaID, m)
+	if err == ErrDupe {
+		return t.storeDupe(m, tag)
+	} else if err != nil {
+		return err
+	}
+	switch Areas[areaID].GetType() {
+	case EchoAreaTypeNetmail:
+		t.stats.Netmail++
+	case EchoAreaTypeBad:
+		t.stats.Bad++
+	default:
+		t.stats.Echomail++
+	}
+	return nil
+}
+
+// tossBad save message failed to toss to bad area
+func (t *tosser) tossBad(m *Message) error {
+	areaID := findAreaByType(EchoAreaTypeBad)
+	if areaID == -1 {
+		return errors.New("bad area not defined")
+	}
+	if m.Area != "" {
+		m.Body = "AREA:" + m.Area + "\x0d" + m.Body
+	}
+	m.AreaID = areaID
+	if err := m.ParseRaw(); err != nil {
+		return err
+	}
+	splitKludges(m)
+	if err := t.store(areaID, m); err != nil {
+		return err
+	}
+	t.stats.Bad++
+	return nil
+}
+
+// store save message to area, CHRS kludge is set to area charset
+func (t *tosser) store(areaID int, m *Message) error {
+	m.AreaID = areaID
+	if _, ok := m.Kludges["CHRS:"]; ok {
+		chrs := config.Config.Chrs.Default
+		if Areas[areaID].GetChrs() != "" {
+			chrs = Areas[areaID].GetChrs()
+		}
+		m.Kludges["CHRS:"] = chrs
+	}
+	m.DateArrived = time.Now()
+	return Areas[areaID].SaveMsg(m)
+}
+
+// storeDupe save dupe to dupe area, dropped if it is not defined
+func (t *tosser) storeDupe(m *Message, tag string) error {
+	t.stats.Dupes++
+	areaID := findAreaByType(EchoAreaTypeDupe)
+	if areaID == -1 {
+		log.Printf("dupe %s in %s dropped", m.Kludges["MSGID:"], tag)
+		return nil
+	}
+	return t.store(areaID, m)
+}
+
+// TossPkt toss single packet
+func TossPkt(fn string) (TossStats, error) {
+	t := &tosser{msgids: make(map[int]map[string]bool)}
+	err := t.tossPkt(fn)
+	return t.stats, err
+}
+
+// tossPkt toss messages of packet, messages not saved even to bad area are
+// written to .bad packet, so it is tossed again without duplicating others
+func (t *tosser) tossPkt(fn string) error {
+	ph, msgs, err := ReadPkt(fn)
+	if err != nil {
+		return err
+	}
+	var failed []*Message
+	for _, m := range msgs {
+		raw := *m
+		if err = t.tossMsg(m); err == nil {
+			continue
+		}
+		log.Printf("%s: %s", fn, err)
+		bad := raw
+		if err = t.tossBad(&bad); err != nil {
+			log.Printf("%s: %s", fn, err)
+			failed = append(failed, &raw)
+		}
+	}
+	t.stats.Packets++
+	if len(failed) > 0 {
+		log.Printf("%s: %d of %d messages not tossed, kept in %s.bad", fn, len(failed), len(msgs), fn)
+		return WritePkt(fn+".bad", ph, failed)
+	}
+	return nil
+}
+
+// Toss toss all packets from inbound directory, tossed packets removed, unreadable renamed to .bad.
+// Messages failed to toss are saved to bad area
+func Toss(inbound string) (TossStats, error) {
+	t := &tosser{msgids: make(map[int]map[string]bool)}
+	dir, err := ioutil.ReadDir(inbound)
+	if err != nil {
+		return t.stats, err
+	}
+	var files []string
+	for _, f := range dir {
+		if !f.IsDir() && strings.EqualFold(filepath.Ext(f.Name()), ".pkt") {
+			files = append(files, filepath.Join(inbound, f.Name()))
+		}
+	}
+	sort.Strings(files)
+	for _, fn := range files {
+		if err = t.tossPkt(fn); err != nil {
+			log.Printf("%s: %s", fn, err)
+			os.Rename(fn, fn+".bad")
+			continue
+		}
+		os.Remove(fn)
+	}
+	return t.stats, nil
+}
@@ -0,0 +1,91 @@
+package msgapi
+
+import (
+	"encoding/binary"
+	. "github.com/franela/goblin"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestToss(t *testing.T) {
+	os.MkdirAll("../../testdata/tosstest/inbound", 0755)
+	Netmail := &MSG{AreaPath: "../../testdata/tosstest/netmail", AreaName: "netmail", AreaType: EchoAreaTypeNetmail}
+	Echo := &JAM{AreaPath: "../../testdata/tosstest/test", AreaName: "test", AreaType: EchoAreaTypeEcho}
+	Bad := &MSG{AreaPath: "../../testdata/tosstest/bad", AreaName: "bad", AreaType: EchoAreaTypeBad}
+	Dupe := &MSG{AreaPath: "../../testdata/tosstest/dupe", AreaName: "dupe", AreaType: EchoAreaTypeDupe}
+	ioutil.WriteFile("../../testdata/tosstest/file", nil, 0644)
+	Broken := &MSG{AreaPath: "../../testdata/tosstest/file/broken", AreaName: "broken", AreaType: EchoAreaTypeEcho}
+	Areas = Areas[:0]
+	Areas = append(Areas, Netmail, Echo, Bad, Dupe, Broken)
+	echo := "AREA:TEST\x0d\x01MSGID: 2:5020/9696.128 00000001\x0dHello\x0d * Origin: test (2:5020/9696.128)\x0dSEEN-BY: 5020/9696\x0d"
+	ioutil.WriteFile("../../testdata/tosstest/inbound/00000001.pkt", makeTestPkt(echo), 0644)
+	ioutil.WriteFile("../../testdata/tosstest/inbound/00000002.pkt", makeTestPkt(echo), 0644)
+	ioutil.WriteFile("../../testdata/tosstest/inbound/00000003.pkt", makeTestPkt("AREA:UNKNOWN\x0dHello\x0d * Origin: test (2:5020/9696.128)\x0d"), 0644)
+	ioutil.WriteFile("../../testdata/tosstest/inbound/00000004.pkt", makeTestPkt("\x01MSGID: 2:5020/9696.128 00000002\x0dNetmail\x0d"), 0644)
+	ioutil.WriteFile("../../testdata/tosstest/inbound/00000005.pkt", makeTestPkt("AREA:BROKEN\x0dHello\x0d * Origin: test (2:5020/9696.128)\x0d"), 0644)
+	// netmail without attributes, stays non-local after toss
+	pkt := makeTestPkt("\x01MSGID: 2:5020/9696.128 00000003\x0dNetmail\x0d")
+	binary.LittleEndian.PutUint16(pkt[pktHdrLen+10:], 0)
+	ioutil.WriteFile("../../testdata/tosstest/inbound/00000006.pkt", pkt, 0644)
+	g := Goblin(t)
+	g.Describe("Check toss", func() {
+		g.It("toss inbound", func() {
+			stats, err := Toss("../../testdata/tosstest/inbound")
+			g.Assert(err).Equal(nil)
+			g.Assert(stats).Equal(TossStats{Packets: 6, Netmail: 2, Echomail: 1, Bad: 2, Dupes: 1})
+			files, _ := ioutil.ReadDir("../../testdata/tosstest/inbound")
+			g.Assert(len(files)).Equal(0)
+		})
+		g.It("check areas", func() {
+			g.Assert(Netmail.GetCount()).Equal(uint32(2))
+			g.Assert(Echo.GetCount()).Equal(uint32(1))
+			g.Assert(Bad.GetCount()).Equal(uint32(2))
+			g.Assert(Dupe.GetCount()).Equal(uint32(1))
+		})
+		g.It("read tossed echomail", func() {
+			m, err := Echo.GetMsg(1)
+			g.Assert(err).Equal(nil)
+			g.Assert(m.Kludges["MSGID:"]).Equal("2:5020/9696.128 00000001")
+			g.Assert(m.Subject).Equal("Test")
+		})
+		g.It("packet attributes kept", func() {
+			m, err := Netmail.GetMsg(2)
+			g.Assert(err).Equal(nil)
+			g.Assert(m.HasAttr("Loc")).IsFalse()
+			m, _ = Netmail.GetMsg(1)
+			g.Assert(m.Attrs).Equal([]string{"Pvt", "Loc"})
+		})
+		g.It("failed message saved to bad area", func() {
+			m, err := Bad.GetMsg(2)
+			g.Assert(err).Equal(nil)
+			g.Assert(strings.HasPrefix(m.Body, "AREA:BROKEN")).IsTrue()
+		})
+		g.It("only messages not tossed kept in .bad packet", func() {
+			Areas = Areas[:0]
+			Areas = append(Areas, Netmail, Echo, Dupe, Broken)
+			fn := "../../testdata/tosstest/inbound/00000007.pkt"
+			var msgs []*Message
+			var ph *PktHeader
+			for i, body := range []string{"AREA:BROKEN\x0dLost\x0d", "AREA:TEST\x0d\x01MSGID: 2:5020/9696.128 00000007\x0dKept\x0d"} {
+				ioutil.WriteFile(fn, makeTestPkt(body), 0644)
+				h, m, _ := ReadPkt(fn)
+				ph = h
+				msgs = append(msgs, m...)
+				g.Assert(len(msgs)).Equal(i + 1)
+			}
+			g.Assert(WritePkt(fn, ph, msgs)).Equal(nil)
+			_, err := Toss("../../testdata/tosstest/inbound")
+			g.Assert(err).Equal(nil)
+			g.Assert(Echo.GetCount()).Equal(uint32(2))
+			_, err = os.Stat(fn)
+			g.Assert(os.IsNotExist(err)).IsTrue()
+			_, bad, err := ReadPkt(fn + ".bad")
+			g.Assert(err).Equal(nil)
+			g.Assert(len(bad)).Equal(1)
+			g.Assert(bad[0].Area).Equal("BROKEN")
+		})
+	})
+	os.RemoveAll("../../testdata/tosstest")
+}
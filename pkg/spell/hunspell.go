@@ -0,0 +1,338 @@
+package spell
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// aff SET encodings
+var affEncodings = map[string]encoding.Encoding{
+	"KOI8-R":           charmap.KOI8R,
+	"KOI8-U":           charmap.KOI8U,
+	"CP1251":           charmap.Windows1251,
+	"MICROSOFT-CP1251": charmap.Windows1251,
+	"WINDOWS-1251":     charmap.Windows1251,
+	"ISO8859-1":        charmap.ISO8859_1,
+	"ISO8859-2":        charmap.ISO8859_2,
+	"ISO8859-5":        charmap.ISO8859_5,
+	"ISO8859-15":       charmap.ISO8859_15,
+}
+
+// affix rule of PFX/SFX group
+type affix struct {
+	flag  string
+	cross bool
+	strip string
+	add   string
+	cond  *regexp.Regexp
+}
+
+// Dict Hunspell dictionary loaded from .aff and .dic pair
+type Dict struct {
+	words     map[string][]string
+	prefixes  map[string][]*affix
+	suffixes  map[string][]*affix
+	cross     map[string]bool
+	flagMode  string
+	aliases   [][]string
+	try       string
+	rep       [][2]string
+	forbidden string
+	needAffix string
+	dec       *encoding.Decoder
+}
+
+// LoadDict loads Hunspell dictionary from aff and dic files
+func LoadDict(aff, dic string) (*Dict, error) {
+	d := &Dict{
+		words:    make(map[string][]string),
+		prefixes: make(map[string][]*affix),
+		suffixes: make(map[string][]*affix),
+		cross:    make(map[string]bool),
+	}
+	if err := d.readFile(aff, d.parseAff); err != nil {
+		return nil, err
+	}
+	if err := d.readFile(dic, d.parseDic); err != nil {
+		return nil, err
+	}
+	if len(d.words) == 0 {
+		return nil, errors.New(dic + ": no words")
+	}
+	return d, nil
+}
+
+// readFile calls parse for every line of fn decoded from dictionary encoding
+func (d *Dict) readFile(fn string, parse func(string, int) error) error {
+	f, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for n := 0; ; n++ {
+		line, err := r.ReadString('\n')
+		if len(line) > 0 {
+			if d.dec != nil {
+				if l, derr := d.dec.String(line); derr == nil {
+					line = l
+				}
+			}
+			if perr := parse(strings.TrimRight(strings.TrimPrefix(line, "\ufeff"), "\r\n"), n); perr != nil {
+				return errors.New(fn + ":" + strconv.Itoa(n+1) + ": " + perr.Error())
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *Dict) parseAff(line string, n int) error {
+	f := strings.Fields(line)
+	if len(f) < 2 || strings.HasPrefix(f[0], "#") {
+		return nil
+	}
+	switch f[0] {
+	case "SET":
+		enc, ok := affEncodings[strings.ToUpper(f[1])]
+		if ok {
+			d.dec = enc.NewDecoder()
+		}
+	case "FLAG":
+		d.flagMode = f[1]
+	case "AF":
+		// first AF line is the count of aliases
+		if _, err := strconv.Atoi(f[1]); err == nil && d.aliases == nil {
+			d.aliases = [][]string{}
+			return nil
+		}
+		d.aliases = append(d.aliases, d.parseFlags(f[1]))
+	case "TRY":
+		d.try = f[1]
+	case "REP":
+		if len(f) > 2 {
+			d.rep = append(d.rep, [2]string{strings.Replace(f[1], "_", " ", -1), strings.Replace(f[2], "_", " ", -1)})
+		}
+	case "FORBIDDENWORD":
+		d.forbidden = d.parseFlags(f[1])[0]
+	case "NEEDAFFIX":
+		d.needAffix = d.parseFlags(f[1])[0]
+	case "PFX", "SFX":
+		return d.parseAffix(f)
+	}
+	return nil
+}
+
+// parseAffix parses PFX/SFX header "SFX A Y 2" and rule "SFX A strip add cond" lines,
+// rules are indexed by added text
+func (d *Dict) parseAffix(f []string) error {
+	key := f[0] + f[1]
+	cross, ok := d.cross[key]
+	if !ok {
+		if len(f) < 4 {
+			return errors.New("bad affix header")
+		}
+		d.cross[key] = f[2] == "Y"
+		return nil
+	}
+	if len(f) < 4 {
+		return errors.New("bad affix rule")
+	}
+	a := &affix{flag: f[1], cross: cross, strip: f[2], add: f[3]}
+	if a.strip == "0" {
+		a.strip = ""
+	}
+	// continuation classes of twofold affixes are not supported
+	if i := strings.Index(a.add, "/"); i != -1 {
+		a.add = a.add[:i]
+	}
+	if a.add == "0" {
+		a.add = ""
+	}
+	cond := "."
+	if len(f) > 4 {
+		cond = f[4]
+	}
+	if cond != "." {
+		var err error
+		if f[0] == "PFX" {
+			a.cond, err = regexp.Compile("^" + condRegexp(cond))
+		} else {
+			a.cond, err = regexp.Compile(condRegexp(cond) + "$")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if f[0] == "PFX" {
+		d.prefixes[a.add] = append(d.prefixes[a.add], a)
+	} else {
+		d.suffixes[a.add] = append(d.suffixes[a.add], a)
+	}
+	return nil
+}
+
+// condRegexp converts affix condition to regexp, only [...], [^...] and . are special
+func condRegexp(cond string) string {
+	var sb strings.Builder
+	inClass := false
+	for _, r := range cond {
+		switch {
+		case r == '[':
+			inClass = true
+			sb.WriteRune(r)
+		case r == ']':
+			inClass = false
+			sb.WriteRune(r)
+		case inClass || r == '.':
+			if r == '\\' {
+				sb.WriteString(`\\`)
+			} else {
+				sb.WriteRune(r)
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return sb.String()
+}
+
+func (d *Dict) parseDic(line string, n int) error {
+	// first line is approximate word count
+	if n == 0 {
+		if _, err := strconv.Atoi(strings.TrimSpace(line)); err == nil {
+			return nil
+		}
+	}
+	if strings.HasPrefix(line, "\t") || strings.TrimSpace(line) == "" {
+		return nil
+	}
+	entry := strings.Fields(line)[0]
+	word, flags := entry, ""
+	if i := strings.Index(entry, "/"); i > 0 {
+		word, flags = entry[:i], entry[i+1:]
+	}
+	var fl []string
+	if flags != "" {
+		if num, err := strconv.Atoi(flags); err == nil && d.aliases != nil {
+			if num > 0 && num <= len(d.aliases) {
+				fl = d.aliases[num-1]
+			}
+		} else {
+			fl = d.parseFlags(flags)
+		}
+	}
+	// homonyms share flags, the key is kept for words without flags
+	d.words[word] = append(d.words[word], fl...)
+	return nil
+}
+
+// parseFlags splits flags string by FLAG mode
+func (d *Dict) parseFlags(s string) []string {
+	var fl []string
+	switch d.flagMode {
+	case "long":
+		r := []rune(s)
+		for i := 0; i+1 < len(r); i += 2 {
+			fl = append(fl, string(r[i:i+2]))
+		}
+	case "num":
+		fl = strings.Split(s, ",")
+	default:
+		for _, r := range s {
+			fl = append(fl, string(r))
+		}
+	}
+	return fl
+}
+
+func hasFlag(flags []string, flag string) bool {
+	if flag == "" {
+		return false
+	}
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// root returns flags of dictionary word, ok is false for unknown and forbidden words
+func (d *Dict) root(word string) ([]string, bool) {
+	flags, ok := d.words[word]
+	if !ok || hasFlag(flags, d.forbidden) {
+		return nil, false
+	}
+	return flags, true
+}
+
+// stripSuffix calls fn for every root and rule that may produce word with suffix
+func (d *Dict) stripSuffix(word string, fn func(base string, a *affix) bool) bool {
+	for i := 1; i <= len(word); i++ {
+		for _, a := range d.suffixes[word[i:]] {
+			base := word[:i] + a.strip
+			if a.cond != nil && !a.cond.MatchString(base) {
+				continue
+			}
+			if fn(base, a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stripPrefix calls fn for every root and rule that may produce word with prefix
+func (d *Dict) stripPrefix(word string, fn func(base string, a *affix) bool) bool {
+	for i := 0; i < len(word); i++ {
+		for _, a := range d.prefixes[word[:i]] {
+			base := a.strip + word[i:]
+			if a.cond != nil && !a.cond.MatchString(base) {
+				continue
+			}
+			if fn(base, a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Check reports whether word in exact case is correct
+func (d *Dict) Check(word string) bool {
+	if flags, ok := d.root(word); ok && !hasFlag(flags, d.needAffix) {
+		return true
+	}
+	if d.stripSuffix(word, func(base string, sfx *affix) bool {
+		if flags, ok := d.root(base); ok && hasFlag(flags, sfx.flag) {
+			return true
+		}
+		if !sfx.cross {
+			return false
+		}
+		// prefix and suffix combined
+		return d.stripPrefix(base, func(root string, pfx *affix) bool {
+			flags, ok := d.root(root)
+			return ok && pfx.cross && hasFlag(flags, pfx.flag) && hasFlag(flags, sfx.flag)
+		})
+	}) {
+		return true
+	}
+	return d.stripPrefix(word, func(base string, pfx *affix) bool {
+		flags, ok := d.root(base)
+		return ok && hasFlag(flags, pfx.flag)
+	})
+}
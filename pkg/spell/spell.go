@@ -0,0 +1,171 @@
+package spell
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxSuggestions limit of Suggest results
+const maxSuggestions = 10
+
+// Checker spell checker over one or more dictionaries, word is correct if any dictionary accepts it
+type Checker struct {
+	dicts []*Dict
+	mu    sync.Mutex
+	cache map[string]bool
+}
+
+// NewChecker returns checker over dictionaries
+func NewChecker(dicts ...*Dict) *Checker {
+	return &Checker{dicts: dicts, cache: make(map[string]bool)}
+}
+
+// LoadDir loads .aff/.dic pairs from dir, langs limits loaded dictionaries by name like en_US
+func LoadDir(dir string, langs []string) (*Checker, error) {
+	if len(langs) == 0 {
+		files, err := filepath.Glob(filepath.Join(dir, "*.dic"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+		for _, fn := range files {
+			langs = append(langs, strings.TrimSuffix(filepath.Base(fn), ".dic"))
+		}
+	}
+	c := NewChecker()
+	for _, lang := range langs {
+		base := filepath.Join(dir, lang)
+		d, err := LoadDict(base+".aff", base+".dic")
+		if err != nil {
+			return nil, err
+		}
+		c.dicts = append(c.dicts, d)
+	}
+	if len(c.dicts) == 0 {
+		return nil, errors.New(dir + ": no dictionaries")
+	}
+	return c, nil
+}
+
+// checkCase checks word in exact case in any dictionary
+func (c *Checker) checkCase(word string) bool {
+	for _, d := range c.dicts {
+		if d.Check(word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check reports whether word is correct, capitalized and upper case forms of
+// dictionary words are accepted
+func (c *Checker) Check(word string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ok, found := c.cache[word]; found {
+		return ok
+	}
+	ok := c.checkCase(word)
+	if !ok {
+		lower := strings.ToLower(word)
+		switch word {
+		case title(lower):
+			ok = c.checkCase(lower)
+		case strings.ToUpper(word):
+			ok = c.checkCase(lower) || c.checkCase(title(lower))
+		}
+	}
+	c.cache[word] = ok
+	return ok
+}
+
+// Add accepts word until exit
+func (c *Checker) Add(word string) {
+	c.mu.Lock()
+	c.cache[word] = true
+	c.mu.Unlock()
+}
+
+func title(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+// edits returns words one edit away from word: replacement table, deleted,
+// swapped, changed and inserted letters and split in two words
+func (d *Dict) edits(word string) []string {
+	var res []string
+	for _, rep := range d.rep {
+		for i := strings.Index(word, rep[0]); i != -1; {
+			res = append(res, word[:i]+rep[1]+word[i+len(rep[0]):])
+			n := strings.Index(word[i+1:], rep[0])
+			if n == -1 {
+				break
+			}
+			i += n + 1
+		}
+	}
+	r := []rune(word)
+	try := []rune(d.try)
+	if len(try) == 0 {
+		try = []rune("abcdefghijklmnopqrstuvwxyz")
+	}
+	for i := range r {
+		res = append(res, string(r[:i])+string(r[i+1:]))
+		if i+1 < len(r) {
+			res = append(res, string(r[:i])+string(r[i+1])+string(r[i])+string(r[i+2:]))
+		}
+		for _, t := range try {
+			if t != r[i] {
+				res = append(res, string(r[:i])+string(t)+string(r[i+1:]))
+			}
+		}
+	}
+	for i := 0; i <= len(r); i++ {
+		for _, t := range try {
+			res = append(res, string(r[:i])+string(t)+string(r[i:]))
+		}
+		if i > 0 && i < len(r) {
+			res = append(res, string(r[:i])+" "+string(r[i:]))
+		}
+	}
+	return res
+}
+
+// Suggest returns corrections of misspelled word
+func (c *Checker) Suggest(word string) []string {
+	lower := strings.ToLower(word)
+	var res []string
+	seen := map[string]bool{word: true}
+	for _, d := range c.dicts {
+		for _, e := range d.edits(lower) {
+			if seen[e] {
+				continue
+			}
+			seen[e] = true
+			ok := true
+			for _, w := range strings.Split(e, " ") {
+				ok = ok && w != "" && c.checkCase(w)
+			}
+			if !ok {
+				continue
+			}
+			switch word {
+			case strings.ToUpper(word):
+				e = strings.ToUpper(e)
+			case title(lower):
+				e = title(e)
+			}
+			res = append(res, e)
+			if len(res) == maxSuggestions {
+				return res
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,53 @@
+package spell
+
+import (
+	. "github.com/franela/goblin"
+	"testing"
+)
+
+func TestSpell(t *testing.T) {
+	g := Goblin(t)
+	g.Describe("Check spell", func() {
+		var c *Checker
+		g.Before(func() {
+			var err error
+			c, err = LoadDir("../../testdata/spell", nil)
+			g.Assert(err == nil).IsTrue()
+		})
+		g.It("dictionary words", func() {
+			g.Assert(c.Check("hello")).IsTrue()
+			g.Assert(c.Check("Hello")).IsTrue()
+			g.Assert(c.Check("HELLO")).IsTrue()
+			g.Assert(c.Check("London")).IsTrue()
+			g.Assert(c.Check("london")).IsFalse()
+			g.Assert(c.Check("helo")).IsFalse()
+			g.Assert(c.Check("colour")).IsFalse()
+		})
+		g.It("affixes", func() {
+			g.Assert(c.Check("worlds")).IsTrue()
+			g.Assert(c.Check("flies")).IsTrue()
+			g.Assert(c.Check("flys")).IsFalse()
+			g.Assert(c.Check("days")).IsTrue()
+			g.Assert(c.Check("undo")).IsTrue()
+			g.Assert(c.Check("unlocked")).IsTrue()
+			g.Assert(c.Check("unhello")).IsFalse()
+		})
+		g.It("koi8-r dictionary", func() {
+			g.Assert(c.Check("привет")).IsTrue()
+			g.Assert(c.Check("домом")).IsTrue()
+			g.Assert(c.Check("Дома")).IsTrue()
+			g.Assert(c.Check("домм")).IsFalse()
+		})
+		g.It("suggestions", func() {
+			g.Assert(c.Suggest("helo")[0]).Equal("hello")
+			g.Assert(c.Suggest("Wrold")[0]).Equal("World")
+			g.Assert(c.Suggest("foto")[0]).Equal("photo")
+			g.Assert(c.Suggest("helloworld")).Equal([]string{"hello world"})
+			g.Assert(c.Suggest("дмо")[0]).Equal("дом")
+		})
+		g.It("session words", func() {
+			c.Add("gossiped")
+			g.Assert(c.Check("gossiped")).IsTrue()
+		})
+	})
+}
@@ -1,7 +1,12 @@
 package ui
 
 import (
+	"github.com/askovpen/gossiped/pkg/config"
+	"github.com/askovpen/gossiped/pkg/spell"
+	"github.com/askovpen/gossiped/pkg/ui/editor"
 	"github.com/rivo/tview"
+	"log"
+	"regexp"
 )
 
 // App ui struct
@@ -13,12 +18,28 @@ type App struct {
 	al          *tview.Table
 	im          IM
 	showKludges bool
+	search      *regexp.Regexp
+	searchStop  chan struct{}
 }
 
 // NewApp return new App
 func NewApp() *App {
 	a := &App{}
 	a.App = tview.NewApplication()
+	editor.DefaultKeyBindings.BindKeys(config.Config.Editor.Keys)
+	editor.OSC52 = config.Config.Editor.OSC52
+	editor.Suspend = a.App.Suspend
+	editor.WordWrap = !config.Config.Editor.NoWrap
+	if config.Config.Editor.Margin > 0 {
+		editor.RightMargin = config.Config.Editor.Margin
+	}
+	if config.Config.Spell.Path != "" {
+		if checker, err := spell.LoadDir(config.Config.Spell.Path, config.Config.Spell.Langs); err != nil {
+			log.Print(err)
+		} else {
+			editor.Spell = checker
+		}
+	}
 
 	a.Pages = tview.NewPages()
 	a.Pages.AddPage(a.AreaList())
@@ -27,6 +48,7 @@ func NewApp() *App {
 
 	a.sb = NewStatusBar(a)
 	a.sb.Run()
+	a.loadAreaStats()
 	a.Layout = tview.NewFlex().
 		SetDirection(tview.FlexRow).
 		AddItem(a.Pages, 0, 1, true).
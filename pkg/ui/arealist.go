@@ -6,7 +6,9 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	// "log"
+	"runtime"
 	"strconv"
+	"sync/atomic"
 )
 
 // AreaListQuit exit app
@@ -82,6 +84,28 @@ func (a *App) AreaList() (string, tview.Primitive, bool, bool) {
 			a.Pages.ShowPage("AreaListQuit")
 		case tcell.KeyF1:
 			a.Pages.ShowPage("AreaListHelp")
+		case tcell.KeyCtrlP:
+			searchString.Clear()
+			row, _ := a.al.GetSelection()
+			if row < 1 {
+				row = 1
+			}
+			if _, ok := msgapi.Areas[row-1].(msgapi.Packer); !ok {
+				a.sb.SetStatus("Pack is supported for JAM, Squish and MSG areas only")
+				return nil
+			}
+			a.Pages.AddPage(a.showPackArea(row - 1))
+			a.Pages.ShowPage("PackAreaModal")
+			return nil
+		case tcell.KeyCtrlS:
+			searchString.Clear()
+			row, _ := a.al.GetSelection()
+			if row < 1 {
+				row = 1
+			}
+			a.Pages.AddPage(a.SearchForm(row - 1))
+			a.Pages.ShowPage("SearchForm")
+			return nil
 		case tcell.KeyRight:
 			searchString.Clear()
 			a.onSelected(a.al.GetSelection())
@@ -97,14 +121,10 @@ func (a *App) AreaList() (string, tview.Primitive, bool, bool) {
 		return event
 	})
 	for i, ar := range msgapi.Areas {
-		if ar.GetCount()-ar.GetLast() > 0 {
-			a.al.SetCell(i+1, 0, tview.NewTableCell(strconv.FormatInt(int64(i), 10)+"[::b]+").SetAlign(tview.AlignRight).SetTextColor(tcell.ColorSilver))
-		} else {
-			a.al.SetCell(i+1, 0, tview.NewTableCell(strconv.FormatInt(int64(i), 10)+" ").SetAlign(tview.AlignRight).SetTextColor(tcell.ColorSilver))
-		}
+		a.al.SetCell(i+1, 0, tview.NewTableCell(strconv.FormatInt(int64(i), 10)+" ").SetAlign(tview.AlignRight).SetTextColor(tcell.ColorSilver))
 		a.al.SetCell(i+1, 1, tview.NewTableCell(ar.GetName()).SetTextColor(tcell.ColorSilver))
-		a.al.SetCell(i+1, 2, tview.NewTableCell(strconv.FormatInt(int64(ar.GetCount()), 10)).SetAlign(tview.AlignRight).SetTextColor(tcell.ColorSilver))
-		a.al.SetCell(i+1, 3, tview.NewTableCell(strconv.FormatInt(int64(ar.GetCount()-ar.GetLast()), 10)).SetAlign(tview.AlignRight).SetTextColor(tcell.ColorSilver))
+		a.al.SetCell(i+1, 2, tview.NewTableCell("...").SetAlign(tview.AlignRight).SetTextColor(tcell.ColorGray))
+		a.al.SetCell(i+1, 3, tview.NewTableCell("...").SetAlign(tview.AlignRight).SetTextColor(tcell.ColorGray))
 	}
 	layout := tview.NewFlex().
 		SetDirection(tview.FlexRow).
@@ -112,6 +132,88 @@ func (a *App) AreaList() (string, tview.Primitive, bool, bool) {
 		AddItem(a.al, 0, 1, true)
 	return "AreaList", layout, true, true
 }
+
+// setAreaCounters fill message counters of area row
+func (a *App) setAreaCounters(areaID int, count uint32, last uint32) {
+	if count-last > 0 {
+		a.al.SetCell(areaID+1, 0, tview.NewTableCell(strconv.FormatInt(int64(areaID), 10)+"[::b]+").SetAlign(tview.AlignRight).SetTextColor(tcell.ColorSilver))
+	} else {
+		a.al.SetCell(areaID+1, 0, tview.NewTableCell(strconv.FormatInt(int64(areaID), 10)+" ").SetAlign(tview.AlignRight).SetTextColor(tcell.ColorSilver))
+	}
+	a.al.SetCell(areaID+1, 2, tview.NewTableCell(strconv.FormatInt(int64(count), 10)).SetAlign(tview.AlignRight).SetTextColor(tcell.ColorSilver))
+	a.al.SetCell(areaID+1, 3, tview.NewTableCell(strconv.FormatInt(int64(count-last), 10)).SetAlign(tview.AlignRight).SetTextColor(tcell.ColorSilver))
+}
+
+// loadAreaStats read area counters in background worker pool
+func (a *App) loadAreaStats() {
+	jobs := make(chan int)
+	total := len(msgapi.Areas)
+	loaded := int32(0)
+	workers := runtime.NumCPU()
+	if workers < 4 {
+		workers = 4
+	}
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				area := msgapi.Detached(msgapi.Areas[i])
+				count := msgapi.CachedCount(area)
+				last := msgapi.CachedLast(area)
+				if last > count {
+					last = count
+				}
+				n := atomic.AddInt32(&loaded, 1)
+				areaID := i
+				a.App.QueueUpdateDraw(func() {
+					a.setAreaCounters(areaID, count, last)
+					if int(n) == total {
+						a.sb.SetStatus(fmt.Sprintf("%d areas loaded", total))
+					} else {
+						a.sb.SetStatus(fmt.Sprintf("Loading areas: %d of %d", n, total))
+					}
+				})
+			}
+		}()
+	}
+	go func() {
+		for i := range msgapi.Areas {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+}
+
+func (a *App) showPackArea(areaID int) (string, tview.Primitive, bool, bool) {
+	modal := NewModalMenu().
+		SetText("Pack " + msgapi.Areas[areaID].GetName() + "?").
+		AddButtons([]string{"Yes", "No"}).
+		SetDoneFunc(func(buttonIndex int) {
+			a.Pages.HidePage("PackAreaModal")
+			a.Pages.RemovePage("PackAreaModal")
+			a.App.SetFocus(a.al)
+			if buttonIndex != 0 {
+				return
+			}
+			a.sb.SetStatus(fmt.Sprintf("Packing %s...", msgapi.Areas[areaID].GetName()))
+			area := msgapi.Detached(msgapi.Areas[areaID])
+			go func() {
+				stats, err := area.(msgapi.Packer).Pack(msgapi.ConfigPackOptions())
+				count, last := area.GetCount(), area.GetLast()
+				a.App.QueueUpdateDraw(func() {
+					msgapi.Reload(msgapi.Areas[areaID])
+					if err != nil {
+						a.sb.SetStatus(err.Error())
+						return
+					}
+					a.setAreaCounters(areaID, count, last)
+					a.sb.SetStatus(fmt.Sprintf("Packed %s: %d -> %d msgs, %d deleted, %d purged",
+						msgapi.Areas[areaID].GetName(), stats.Before, stats.After, stats.Deleted, stats.Purged))
+				})
+			}()
+		})
+	return "PackAreaModal", modal, true, true
+}
+
 func (a *App) onSelected(row int, column int) {
 	if row < 1 {
 		row = 1
@@ -23,8 +23,16 @@ type EditHeader struct {
 	sCoords   [5]coords
 	done      func([5][]rune)
 	msg       *msgapi.Message
+	attrFocus bool
+	attrPos   int
 }
 
+// header line and start column of netmail attributes, subject moves below them
+const (
+	attrY = 3
+	attrX = 8
+)
+
 // NewEditHeader create new EditHeader
 func NewEditHeader(msg *msgapi.Message) *EditHeader {
 	eh := &EditHeader{
@@ -47,6 +55,9 @@ func NewEditHeader(msg *msgapi.Message) *EditHeader {
 		sIndex:    0,
 		msg:       msg,
 	}
+	if eh.isNetmail() {
+		eh.sCoords[4].y = attrY + 1
+	}
 	return eh
 }
 
@@ -57,7 +68,15 @@ func (e *EditHeader) Draw(screen tcell.Screen) {
 	tview.Print(screen, "Msg  :", x+1, y, 6, 0, tcell.ColorSilver)
 	tview.Print(screen, "From :", x+1, y+1, 6, 0, tcell.ColorSilver)
 	tview.Print(screen, "To   :", x+1, y+2, 6, 0, tcell.ColorSilver)
-	tview.Print(screen, "Subj :", x+1, y+3, 6, 0, tcell.ColorSilver)
+	tview.Print(screen, "Subj :", x+1, y+e.sCoords[4].y, 6, 0, tcell.ColorSilver)
+	if e.isNetmail() {
+		tview.Print(screen, "Attr :", x+1, y+attrY, 6, 0, tcell.ColorSilver)
+		e.drawAttrs(screen, x, y)
+	}
+	if e.HasFocus() && e.attrFocus {
+		screen.ShowCursor(x+attrX+e.attrPos*4, y+attrY)
+		return
+	}
 	if e.HasFocus() {
 		for i := e.sCoords[e.sIndex].f; i < e.sCoords[e.sIndex].t; i++ {
 			screen.SetContent(x+i, y+e.sCoords[e.sIndex].y, ' ', nil, tcell.StyleDefault.Background(tcell.ColorNavy))
@@ -71,6 +90,68 @@ func (e *EditHeader) Draw(screen tcell.Screen) {
 	}
 }
 
+// Height rows of header with border, netmail adds attributes line
+func (e *EditHeader) Height() int {
+	return e.sCoords[4].y + 3
+}
+
+func (e *EditHeader) isNetmail() bool {
+	return msgapi.Areas[e.msg.AreaID].GetType() == msgapi.EchoAreaTypeNetmail
+}
+
+// drawAttrs draw attribute switches, set ones highlighted
+func (e *EditHeader) drawAttrs(screen tcell.Screen, x, y int) {
+	for i, attr := range msgapi.NetmailAttrs {
+		style := tcell.StyleDefault.Foreground(tcell.ColorGray)
+		if e.msg.HasAttr(attr) {
+			style = tcell.StyleDefault.Foreground(tcell.ColorYellow).Bold(true)
+		}
+		if e.HasFocus() && e.attrFocus && i == e.attrPos {
+			style = style.Background(tcell.ColorNavy)
+		}
+		for j, r := range attr {
+			screen.SetContent(x+attrX+i*4+j, y+attrY, r, nil, style)
+		}
+	}
+}
+
+// nextField move focus to next field, attributes go between To address and subject
+func (e *EditHeader) nextField() {
+	if e.attrFocus {
+		e.attrFocus = false
+		e.sIndex = 4
+		return
+	}
+	e.sIndex++
+	if e.sIndex == 5 {
+		e.sIndex = 0
+	} else if !e.isNetmail() && e.sIndex == 3 {
+		e.sIndex = 4
+	} else if e.isNetmail() && e.sIndex == 4 {
+		e.attrFocus = true
+	}
+}
+
+// attrInput handle keys on attribute switches
+func (e *EditHeader) attrInput(event *tcell.EventKey) {
+	switch event.Key() {
+	case tcell.KeyTab, tcell.KeyEnter:
+		e.nextField()
+	case tcell.KeyRight:
+		if e.attrPos < len(msgapi.NetmailAttrs)-1 {
+			e.attrPos++
+		}
+	case tcell.KeyLeft:
+		if e.attrPos > 0 {
+			e.attrPos--
+		}
+	case tcell.KeyRune:
+		if event.Rune() == ' ' {
+			e.msg.ToggleAttr(msgapi.NetmailAttrs[e.attrPos])
+		}
+	}
+}
+
 // InputHandler event handler
 func (e *EditHeader) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
 	return e.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
@@ -80,14 +161,13 @@ func (e *EditHeader) InputHandler() func(event *tcell.EventKey, setFocus func(p
 			e.sInputs[e.sIndex][e.sPosition[e.sIndex]] = r
 			e.sPosition[e.sIndex]++
 		}
+		if e.attrFocus {
+			e.attrInput(event)
+			return
+		}
 		switch key := event.Key(); key {
 		case tcell.KeyTab:
-			e.sIndex++
-			if e.sIndex == 5 {
-				e.sIndex = 0
-			} else if msgapi.Areas[e.msg.AreaID].GetType() != msgapi.EchoAreaTypeNetmail && e.sIndex == 3 {
-				e.sIndex = 4
-			}
+			e.nextField()
 		case tcell.KeyRight:
 			if e.sPosition[e.sIndex] < len(e.sInputs[e.sIndex]) {
 				e.sPosition[e.sIndex]++
@@ -104,10 +184,7 @@ func (e *EditHeader) InputHandler() func(event *tcell.EventKey, setFocus func(p
 					}
 				}
 			} else {
-				e.sIndex++
-				if msgapi.Areas[e.msg.AreaID].GetType() != msgapi.EchoAreaTypeNetmail && e.sIndex == 3 {
-					e.sIndex = 4
-				}
+				e.nextField()
 			}
 		case tcell.KeyBackspace, tcell.KeyBackspace2:
 			if e.sPosition[e.sIndex] > 0 {
@@ -359,10 +359,12 @@ func (v *View) DeleteLine() bool {
 	return true
 }
 
-// DeleteToEnd deletes the current line
+// DeleteToEnd deletes from the cursor to the end of the line
 func (v *View) DeleteToEnd() bool {
-	x, y := runeToByteIndex(v.Cursor.Loc.X, v.Buf.LineBytes(v.Cursor.Loc.Y)), v.Cursor.Loc.Y
-	v.Buf.DeleteToEnd(Loc{x, y})
+	end := Loc{Count(v.Buf.Line(v.Cursor.Y)), v.Cursor.Y}
+	if v.Cursor.Loc.LessThan(end) {
+		v.Buf.Remove(v.Cursor.Loc, end)
+	}
 	return true
 }
 
@@ -470,6 +472,173 @@ func (v *View) ToggleOverwriteMode() bool {
 	return false
 }
 
+// SelectUp selects up one line
+func (v *View) SelectUp() bool {
+	if !v.Cursor.HasSelection() {
+		v.Cursor.OrigSelection[0] = v.Cursor.Loc
+	}
+	v.Cursor.Up()
+	v.Cursor.SelectTo(v.Cursor.Loc)
+	return true
+}
+
+// SelectDown selects down one line
+func (v *View) SelectDown() bool {
+	if !v.Cursor.HasSelection() {
+		v.Cursor.OrigSelection[0] = v.Cursor.Loc
+	}
+	v.Cursor.Down()
+	v.Cursor.SelectTo(v.Cursor.Loc)
+	return true
+}
+
+// SelectLeft selects the character to the left of the cursor
+func (v *View) SelectLeft() bool {
+	if !v.Cursor.HasSelection() {
+		v.Cursor.OrigSelection[0] = v.Cursor.Loc
+	}
+	v.Cursor.Left()
+	v.Cursor.SelectTo(v.Cursor.Loc)
+	return true
+}
+
+// SelectRight selects the character to the right of the cursor
+func (v *View) SelectRight() bool {
+	if !v.Cursor.HasSelection() {
+		v.Cursor.OrigSelection[0] = v.Cursor.Loc
+	}
+	v.Cursor.Right()
+	v.Cursor.SelectTo(v.Cursor.Loc)
+	return true
+}
+
+// SelectToStartOfLine selects to the start of the current line
+func (v *View) SelectToStartOfLine() bool {
+	if !v.Cursor.HasSelection() {
+		v.Cursor.OrigSelection[0] = v.Cursor.Loc
+	}
+	v.Cursor.Start()
+	v.Cursor.SelectTo(v.Cursor.Loc)
+	return true
+}
+
+// SelectToEndOfLine selects to the end of the current line
+func (v *View) SelectToEndOfLine() bool {
+	if !v.Cursor.HasSelection() {
+		v.Cursor.OrigSelection[0] = v.Cursor.Loc
+	}
+	v.Cursor.End()
+	v.Cursor.SelectTo(v.Cursor.Loc)
+	return true
+}
+
+// SelectLine selects the entire current line
+func (v *View) SelectLine() bool {
+	v.Cursor.SelectLine()
+	return true
+}
+
+// SelectAll selects the entire buffer
+func (v *View) SelectAll() bool {
+	v.Cursor.OrigSelection[0] = v.Buf.Start()
+	v.Cursor.SetSelectionStart(v.Buf.Start())
+	v.Cursor.SetSelectionEnd(v.Buf.End())
+	v.Cursor.Loc = v.Buf.End()
+	return true
+}
+
+// Copy the selection to the clipboard
+func (v *View) Copy() bool {
+	if v.mainCursor() && v.Cursor.HasSelection() {
+		SetClipboard(v.Cursor.GetSelection())
+	}
+	return true
+}
+
+// Cut the selection to the clipboard, current line if nothing is selected
+func (v *View) Cut() bool {
+	if v.Readonly {
+		return false
+	}
+	if !v.Cursor.HasSelection() {
+		v.Cursor.SelectLine()
+	}
+	if !v.Cursor.HasSelection() {
+		return false
+	}
+	SetClipboard(v.Cursor.GetSelection())
+	v.Cursor.DeleteSelection()
+	v.Cursor.ResetSelection()
+	return true
+}
+
+// Paste the clipboard, replacing the selection
+func (v *View) Paste() bool {
+	if v.Readonly || clipboard == "" {
+		return false
+	}
+	if v.Cursor.HasSelection() {
+		v.Cursor.DeleteSelection()
+		v.Cursor.ResetSelection()
+	}
+	v.Buf.Insert(v.Cursor.Loc, clipboard)
+	return true
+}
+
+// selectedLines returns the lines covered by the selection or the cursor line,
+// end is exclusive
+func (v *View) selectedLines() (start, end int) {
+	if !v.Cursor.HasSelection() {
+		return v.Cursor.Y, v.Cursor.Y + 1
+	}
+	a, b := v.Cursor.CurSelection[0], v.Cursor.CurSelection[1]
+	if a.GreaterThan(b) {
+		a, b = b, a
+	}
+	if b.X > 0 || b.Y == a.Y {
+		b.Y++
+	}
+	return a.Y, b.Y
+}
+
+// MoveLinesUp moves up the current line or selected lines if any
+func (v *View) MoveLinesUp() bool {
+	if v.Readonly {
+		return false
+	}
+	if start, end := v.selectedLines(); start > 0 {
+		v.Buf.MoveLinesUp(start, end)
+	}
+	return true
+}
+
+// MoveLinesDown moves down the current line or selected lines if any
+func (v *View) MoveLinesDown() bool {
+	if v.Readonly {
+		return false
+	}
+	if start, end := v.selectedLines(); end < v.Buf.NumLines-1 {
+		v.Buf.MoveLinesDown(start, end)
+	}
+	return true
+}
+
+// Undo undoes the last group of edits
+func (v *View) Undo() bool {
+	if v.mainCursor() {
+		v.Buf.Undo()
+	}
+	return true
+}
+
+// Redo redoes the last undone group of edits
+func (v *View) Redo() bool {
+	if v.mainCursor() {
+		v.Buf.Redo()
+	}
+	return true
+}
+
 // Escape leaves current mode
 func (v *View) Escape() bool {
 	v.done()
@@ -39,6 +39,25 @@ const (
 	ActionToggleOverwriteMode = "ToggleOverwriteMode"
 	ActionEscape              = "Escape"
 	ActionInsertEnter         = "InsertEnter"
+	ActionUndo                = "Undo"
+	ActionRedo                = "Redo"
+	ActionSelectUp            = "SelectUp"
+	ActionSelectDown          = "SelectDown"
+	ActionSelectLeft          = "SelectLeft"
+	ActionSelectRight         = "SelectRight"
+	ActionSelectToStartOfLine = "SelectToStartOfLine"
+	ActionSelectToEndOfLine   = "SelectToEndOfLine"
+	ActionSelectLine          = "SelectLine"
+	ActionSelectAll           = "SelectAll"
+	ActionCopy                = "Copy"
+	ActionCut                 = "Cut"
+	ActionPaste               = "Paste"
+	ActionMoveLinesUp         = "MoveLinesUp"
+	ActionMoveLinesDown       = "MoveLinesDown"
+	ActionFindNext            = "FindNext"
+	ActionFindPrevious        = "FindPrevious"
+	ActionReformatParagraph   = "ReformatParagraph"
+	ActionReformatQuote       = "ReformatQuote"
 	ActionUnbindKey           = "UnbindKey"
 )
 
@@ -128,6 +147,25 @@ var bindingActions = map[string]func(*View) bool{
 	ActionToggleOverwriteMode: (*View).ToggleOverwriteMode,
 	ActionEscape:              (*View).Escape,
 	ActionInsertEnter:         (*View).InsertNewline,
+	ActionUndo:                (*View).Undo,
+	ActionRedo:                (*View).Redo,
+	ActionSelectUp:            (*View).SelectUp,
+	ActionSelectDown:          (*View).SelectDown,
+	ActionSelectLeft:          (*View).SelectLeft,
+	ActionSelectRight:         (*View).SelectRight,
+	ActionSelectToStartOfLine: (*View).SelectToStartOfLine,
+	ActionSelectToEndOfLine:   (*View).SelectToEndOfLine,
+	ActionSelectLine:          (*View).SelectLine,
+	ActionSelectAll:           (*View).SelectAll,
+	ActionCopy:                (*View).Copy,
+	ActionCut:                 (*View).Cut,
+	ActionPaste:               (*View).Paste,
+	ActionMoveLinesUp:         (*View).MoveLinesUp,
+	ActionMoveLinesDown:       (*View).MoveLinesDown,
+	ActionFindNext:            (*View).FindNext,
+	ActionFindPrevious:        (*View).FindPrevious,
+	ActionReformatParagraph:   (*View).ReformatParagraph,
+	ActionReformatQuote:       (*View).ReformatQuote,
 }
 
 var bindingKeys = map[string]tcell.Key{
@@ -267,27 +305,48 @@ var DefaultKeyBindings KeyBindings
 // InitBindings initializes the keybindings for micro
 func init() {
 	DefaultKeyBindings = NewKeyBindings(map[string]string{
-		"Up":        ActionCursorUp,
-		"Down":      ActionCursorDown,
-		"Right":     ActionCursorRight,
-		"Left":      ActionCursorLeft,
-		"Enter":     ActionInsertNewline,
-		"CtrlH":     ActionBackspace,
-		"Backspace": ActionBackspace,
-		"Tab":       ActionIndentSelection + "," + ActionInsertTab,
-		"Backtab":   ActionOutdentSelection + "," + ActionOutdentLine,
-		"CtrlY":     ActionDeleteLine,
-		"CtrlK":     ActionDeleteToEnd,
-		"Home":      ActionStartOfLine,
-		"End":       ActionEndOfLine,
-		"CtrlHome":  ActionCursorStart,
-		"CtrlEnd":   ActionCursorEnd,
-		"PageUp":    ActionCursorPageUp,
-		"PageDown":  ActionCursorPageDown,
-		"Delete":    ActionDelete,
-		"Insert":    ActionToggleOverwriteMode,
-		"Esc":       ActionEscape,
-		"F2":        ActionEscape,
+		"Up":          ActionCursorUp,
+		"Down":        ActionCursorDown,
+		"Right":       ActionCursorRight,
+		"Left":        ActionCursorLeft,
+		"Enter":       ActionInsertNewline,
+		"CtrlH":       ActionBackspace,
+		"Backspace":   ActionBackspace,
+		"Tab":         ActionIndentSelection + "," + ActionInsertTab,
+		"Backtab":     ActionOutdentSelection + "," + ActionOutdentLine,
+		"CtrlY":       ActionDeleteLine,
+		"CtrlK":       ActionDeleteToEnd,
+		"Home":        ActionStartOfLine,
+		"End":         ActionEndOfLine,
+		"CtrlHome":    ActionCursorStart,
+		"CtrlEnd":     ActionCursorEnd,
+		"PageUp":      ActionCursorPageUp,
+		"PageDown":    ActionCursorPageDown,
+		"Delete":      ActionDelete,
+		"Insert":      ActionToggleOverwriteMode,
+		"Esc":         ActionEscape,
+		"F2":          ActionEscape,
+		"CtrlZ":       ActionUndo,
+		"CtrlR":       ActionRedo,
+		"ShiftUp":     ActionSelectUp,
+		"ShiftDown":   ActionSelectDown,
+		"ShiftLeft":   ActionSelectLeft,
+		"ShiftRight":  ActionSelectRight,
+		"ShiftHome":   ActionSelectToStartOfLine,
+		"ShiftEnd":    ActionSelectToEndOfLine,
+		"CtrlL":       ActionSelectLine,
+		"CtrlA":       ActionSelectAll,
+		"CtrlInsert":  ActionCopy,
+		"CtrlX":       ActionCut,
+		"ShiftDelete": ActionCut,
+		"CtrlV":       ActionPaste,
+		"ShiftInsert": ActionPaste,
+		"AltUp":       ActionMoveLinesUp,
+		"AltDown":     ActionMoveLinesDown,
+		"CtrlN":       ActionFindNext,
+		"CtrlP":       ActionFindPrevious,
+		"CtrlB":       ActionReformatParagraph,
+		"CtrlQ":       ActionReformatQuote,
 	})
 }
 
@@ -2,6 +2,8 @@ package editor
 
 import (
 	"io"
+	"regexp"
+	"sort"
 	"strings"
 	"unicode/utf8"
 
@@ -46,6 +48,9 @@ type Buffer struct {
 	syntaxDef   *highlight.Def
 	highlighter *highlight.Highlighter
 
+	// Search matches are highlighted on top of the syntax
+	search *regexp.Regexp
+
 	// Buffer local settings
 	Settings map[string]interface{}
 }
@@ -328,6 +333,84 @@ func (b *Buffer) MoveLinesDown(start int, end int) {
 	)
 }
 
+// SetSearch sets the pattern highlighted in the buffer, nil clears it
+func (b *Buffer) SetSearch(re *regexp.Regexp) {
+	b.search = re
+	b.ClearMatches()
+	if b.highlighter != nil {
+		b.highlighter.HighlightStates(b)
+	}
+}
+
+// FindNext returns the location of the first search match at or after loc
+func (b *Buffer) FindNext(loc Loc) (Loc, bool) {
+	if b.search == nil {
+		return loc, false
+	}
+	for y := loc.Y; y < b.LinesNum(); y++ {
+		line := b.Line(y)
+		from := 0
+		if y == loc.Y {
+			from = len(string(b.LineRunes(y)[:min(loc.X, len(b.LineRunes(y)))]))
+		}
+		if m := b.search.FindStringIndex(line[from:]); m != nil {
+			return Loc{utf8.RuneCountInString(line[:from+m[0]]), y}, true
+		}
+	}
+	return loc, false
+}
+
+// highlightSearch overlays search matches on the syntax matches of lines
+func (b *Buffer) highlightSearch(start, end int) {
+	if b.search == nil {
+		return
+	}
+	group := highlight.GetGroup("search")
+	for i := start; i < end && i < b.LinesNum(); i++ {
+		b.overlayMatch(i, b.lineMatches(i), group)
+	}
+}
+
+// overlayMatch colors rune ranges of line i with group on top of its syntax matches
+func (b *Buffer) overlayMatch(i int, locs [][2]int, group highlight.Group) {
+	if len(locs) == 0 {
+		return
+	}
+	orig := b.Match(i)
+	keys := make([]int, 0, len(orig))
+	for k := range orig {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	groupAt := func(pos int) highlight.Group {
+		var g highlight.Group
+		for _, k := range keys {
+			if k > pos {
+				break
+			}
+			g = orig[k]
+		}
+		return g
+	}
+	match := make(highlight.LineMatch)
+	for k, v := range orig {
+		match[k] = v
+	}
+	for _, l := range locs {
+		s, e := l[0], l[1]
+		for k := range match {
+			if k > s && k < e {
+				delete(match, k)
+			}
+		}
+		if _, ok := orig[e]; !ok {
+			match[e] = groupAt(e)
+		}
+		match[s] = group
+	}
+	b.SetMatch(i, match)
+}
+
 // ClearMatches clears all of the syntax highlighting for this buffer
 func (b *Buffer) ClearMatches() {
 	for i := range b.lines {
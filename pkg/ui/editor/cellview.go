@@ -64,6 +64,8 @@ type Char struct {
 // CellView struct
 type CellView struct {
 	lines [][]*Char
+	// spell enables misspelled words highlighting, off in read-only views
+	spell bool
 }
 
 // Draw func
@@ -91,6 +93,10 @@ func (c *CellView) Draw(buf *Buffer, colorscheme Colorscheme, top, height, left,
 
 		buf.highlighter.HighlightMatches(buf, top, top+height)
 	}
+	if c.spell {
+		buf.highlightSpell(top, top+height)
+	}
+	buf.highlightSearch(top, top+height)
 
 	c.lines = make([][]*Char, 0)
 
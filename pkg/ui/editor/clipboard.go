@@ -0,0 +1,38 @@
+package editor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+// OSC52 also exports copied text to the terminal clipboard
+var OSC52 bool
+
+// Suspend runs f with terminal released by screen, tview Application.Suspend
+var Suspend func(f func()) bool
+
+// clipboard is shared by all views, so text moves between messages
+var clipboard string
+
+// SetClipboard replaces the clipboard contents
+func SetClipboard(text string) {
+	clipboard = text
+	if !OSC52 {
+		return
+	}
+	osc := func() {
+		fmt.Fprintf(os.Stdout, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	}
+	if Suspend == nil {
+		osc()
+		return
+	}
+	// tcell owns the terminal, do not write between its updates
+	Suspend(osc)
+}
+
+// Clipboard returns the clipboard contents
+func Clipboard() string {
+	return clipboard
+}
@@ -14,6 +14,9 @@ const (
 	TextEventReplace = 0
 )
 
+// undoThreshold pause that ends a typing burst
+const undoThreshold = time.Second
+
 // TextEvent holds data for a manipulation on some text that can be undone
 type TextEvent struct {
 	C Cursor
@@ -21,6 +24,8 @@ type TextEvent struct {
 	EventType int
 	Deltas    []Delta
 	Time      time.Time
+	// Group events undone and redone together
+	Group int
 }
 
 // A Delta is a change to the buffer
@@ -53,18 +58,42 @@ func ExecuteTextEvent(t *TextEvent, buf *Buffer) {
 	}
 }
 
+// UndoTextEvent undoes a text event
+func UndoTextEvent(t *TextEvent, buf *Buffer) {
+	t.EventType = -t.EventType
+	ExecuteTextEvent(t, buf)
+}
+
 // EventHandler executes text manipulations and allows undoing and redoing
 type EventHandler struct {
-	buf *Buffer
+	buf       *Buffer
+	UndoStack *Stack
+	RedoStack *Stack
+	group     int
 }
 
 // NewEventHandler returns a new EventHandler
 func NewEventHandler(buf *Buffer) *EventHandler {
 	eh := new(EventHandler)
 	eh.buf = buf
+	eh.UndoStack = new(Stack)
+	eh.RedoStack = new(Stack)
 	return eh
 }
 
+// Checkpoint starts a new undo group, following events are undone together
+func (eh *EventHandler) Checkpoint() {
+	eh.group++
+}
+
+// Typing starts a new undo group unless a typing burst continues
+func (eh *EventHandler) Typing() {
+	t := eh.UndoStack.Peek()
+	if t == nil || t.Group != eh.group || time.Since(t.Time) > undoThreshold {
+		eh.Checkpoint()
+	}
+}
+
 // Insert creates an insert text event and executes it
 func (eh *EventHandler) Insert(start Loc, text string) {
 	e := &TextEvent{
@@ -143,5 +172,79 @@ func (eh *EventHandler) Replace(start, end Loc, replace string) {
 
 // Execute a textevent and add it to the undo stack
 func (eh *EventHandler) Execute(t *TextEvent) {
+	if eh.RedoStack.Len() > 0 {
+		eh.RedoStack = new(Stack)
+	}
+	t.Group = eh.group
+	eh.UndoStack.Push(t)
 	ExecuteTextEvent(t, eh.buf)
 }
+
+// Undo the last group of events in the undo stack
+func (eh *EventHandler) Undo() bool {
+	t := eh.UndoStack.Peek()
+	if t == nil {
+		return false
+	}
+	group := t.Group
+	for t != nil && t.Group == group {
+		eh.UndoOneEvent()
+		t = eh.UndoStack.Peek()
+	}
+	// typing after undo must not join the undone group
+	eh.Checkpoint()
+	return true
+}
+
+// UndoOneEvent undoes one event
+func (eh *EventHandler) UndoOneEvent() {
+	t := eh.UndoStack.Pop()
+	if t == nil {
+		return
+	}
+	UndoTextEvent(t, eh.buf)
+	eh.restoreCursor(t)
+	eh.RedoStack.Push(t)
+}
+
+// Redo the last group of events in the redo stack
+func (eh *EventHandler) Redo() bool {
+	t := eh.RedoStack.Peek()
+	if t == nil {
+		return false
+	}
+	group := t.Group
+	for t != nil && t.Group == group {
+		eh.RedoOneEvent()
+		t = eh.RedoStack.Peek()
+	}
+	eh.Checkpoint()
+	return true
+}
+
+// RedoOneEvent redoes one event
+func (eh *EventHandler) RedoOneEvent() {
+	t := eh.RedoStack.Pop()
+	if t == nil {
+		return
+	}
+	// Modifies the text event
+	UndoTextEvent(t, eh.buf)
+	if t.C.Num >= 0 && t.C.Num < len(eh.buf.cursors) && len(t.Deltas) > 0 {
+		c := eh.buf.cursors[t.C.Num]
+		c.ResetSelection()
+		if t.EventType == TextEventRemove {
+			c.GotoLoc(t.Deltas[0].Start)
+		} else {
+			c.GotoLoc(t.Deltas[0].End)
+		}
+	}
+	eh.UndoStack.Push(t)
+}
+
+// restoreCursor puts the cursor where it was before the event
+func (eh *EventHandler) restoreCursor(t *TextEvent) {
+	if t.C.Num >= 0 && t.C.Num < len(eh.buf.cursors) {
+		eh.buf.cursors[t.C.Num].Goto(t.C)
+	}
+}
@@ -0,0 +1,198 @@
+package editor
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// lineMatches returns rune locations of search matches in line y
+func (b *Buffer) lineMatches(y int) [][2]int {
+	line := b.Line(y)
+	var locs [][2]int
+	for _, m := range b.search.FindAllStringIndex(line, -1) {
+		if m[0] == m[1] {
+			continue
+		}
+		locs = append(locs, [2]int{utf8.RuneCountInString(line[:m[0]]), utf8.RuneCountInString(line[:m[1]])})
+	}
+	return locs
+}
+
+// findMatch returns the first match after from, or the last one before it
+// searching backward, wrapping around the buffer
+func (b *Buffer) findMatch(from Loc, backward bool) (Loc, Loc, bool) {
+	if b.search == nil {
+		return from, from, false
+	}
+	n := b.LinesNum()
+	for i := 0; i <= n; i++ {
+		y := from.Y + i
+		if backward {
+			y = from.Y - i
+		}
+		y = (y%n + n) % n
+		locs := b.lineMatches(y)
+		if backward {
+			for j := len(locs) - 1; j >= 0; j-- {
+				if i > 0 || locs[j][0] < from.X {
+					return Loc{locs[j][0], y}, Loc{locs[j][1], y}, true
+				}
+			}
+			continue
+		}
+		for _, l := range locs {
+			if i > 0 || l[0] >= from.X {
+				return Loc{l[0], y}, Loc{l[1], y}, true
+			}
+		}
+	}
+	return from, from, false
+}
+
+// expand returns the replacement of the match at start, with $1 style groups expanded
+func (b *Buffer) expand(start, end Loc, repl string) string {
+	line := b.Line(start.Y)
+	runes := []rune(line)
+	s, e := len(string(runes[:start.X])), len(string(runes[:end.X]))
+	for _, m := range b.search.FindAllStringSubmatchIndex(line, -1) {
+		if m[0] == s && m[1] == e {
+			return string(b.search.ExpandString(nil, repl, line, m))
+		}
+	}
+	return repl
+}
+
+// ReplaceAll replaces every search match in the buffer as one undoable event,
+// returns the number of replaced matches
+func (b *Buffer) ReplaceAll(repl string) int {
+	if b.search == nil {
+		return 0
+	}
+	var deltas []Delta
+	count := 0
+	for y := 0; y < b.LinesNum(); y++ {
+		line := b.Line(y)
+		var text []byte
+		last, n := 0, 0
+		// empty matches are skipped as in lineMatches
+		for _, m := range b.search.FindAllStringSubmatchIndex(line, -1) {
+			if m[0] == m[1] {
+				continue
+			}
+			text = append(text, line[last:m[0]]...)
+			text = b.search.ExpandString(text, repl, line, m)
+			last = m[1]
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		count += n
+		deltas = append(deltas, Delta{
+			Text:  string(append(text, line[last:]...)),
+			Start: Loc{0, y},
+			End:   Loc{utf8.RuneCountInString(line), y},
+		})
+	}
+	if len(deltas) > 0 {
+		b.MultipleReplace(deltas)
+	}
+	return count
+}
+
+// LiteralReplacement escapes repl for use without regexp group expansion
+func LiteralReplacement(repl string) string {
+	return strings.Replace(repl, "$", "$$", -1)
+}
+
+// Search highlights matches of re and selects the first one from loc,
+// used for incremental search while the pattern is typed
+func (v *View) Search(re *regexp.Regexp, loc Loc, backward bool) bool {
+	v.Buf.SetSearch(re)
+	v.searchBackward = backward
+	v.Cursor.ResetSelection()
+	if re == nil {
+		return false
+	}
+	start, end, ok := v.Buf.findMatch(loc, backward)
+	if !ok {
+		v.Cursor.GotoLoc(loc)
+		v.Relocate()
+		return false
+	}
+	v.selectMatch(start, end)
+	return true
+}
+
+// ClearSearch removes search highlighting
+func (v *View) ClearSearch() {
+	v.Buf.SetSearch(nil)
+}
+
+func (v *View) selectMatch(start, end Loc) {
+	v.Cursor.SetSelectionStart(start)
+	v.Cursor.SetSelectionEnd(end)
+	v.Cursor.OrigSelection = v.Cursor.CurSelection
+	v.Cursor.GotoLoc(end)
+	v.Relocate()
+}
+
+func (v *View) find(backward bool) bool {
+	from := v.Cursor.Loc
+	if v.Cursor.HasSelection() {
+		from = v.Cursor.CurSelection[1]
+		if backward {
+			from = v.Cursor.CurSelection[0]
+		}
+	}
+	start, end, ok := v.Buf.findMatch(from, backward)
+	if ok {
+		v.selectMatch(start, end)
+	}
+	return ok
+}
+
+// FindNext selects the next match in search direction
+func (v *View) FindNext() bool {
+	return v.find(v.searchBackward)
+}
+
+// FindPrevious selects the next match against search direction
+func (v *View) FindPrevious() bool {
+	return v.find(!v.searchBackward)
+}
+
+// ReplaceNext replaces the selected match and selects the next one
+func (v *View) ReplaceNext(repl string) bool {
+	if v.Readonly || v.Buf.search == nil {
+		return false
+	}
+	v.Buf.Checkpoint()
+	if v.Cursor.HasSelection() {
+		start, end := v.Cursor.CurSelection[0], v.Cursor.CurSelection[1]
+		if s, e, ok := v.Buf.findMatch(start, false); ok && s == start && e == end {
+			text := v.Buf.expand(start, end, repl)
+			v.Cursor.ResetSelection()
+			v.Buf.Replace(start, end, text)
+			if v.searchBackward {
+				v.Cursor.GotoLoc(start)
+			} else {
+				v.Cursor.GotoLoc(start.Move(Count(text), v.Buf))
+			}
+		}
+	}
+	return v.find(v.searchBackward)
+}
+
+// ReplaceAll replaces every match, returns the number of replacements
+func (v *View) ReplaceAll(repl string) int {
+	if v.Readonly {
+		return 0
+	}
+	v.Buf.Checkpoint()
+	v.Cursor.ResetSelection()
+	n := v.Buf.ReplaceAll(repl)
+	v.Cursor.Relocate()
+	return n
+}
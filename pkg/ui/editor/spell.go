@@ -0,0 +1,112 @@
+package editor
+
+import (
+	"github.com/askovpen/gossiped/pkg/highlight"
+	"strings"
+	"unicode"
+)
+
+// SpellChecker checks words of message text
+type SpellChecker interface {
+	Check(word string) bool
+	Suggest(word string) []string
+	Add(word string)
+}
+
+// Spell highlights misspelled words when set
+var Spell SpellChecker
+
+// spellSkipLine reports lines not checked: quotes, kludges, tearline and origin
+func spellSkipLine(line string) bool {
+	return QuotePrefix(line) != "" ||
+		strings.HasPrefix(line, "@") || strings.HasPrefix(line, "\x01") ||
+		strings.HasPrefix(line, "SEEN-BY: ") ||
+		line == "---" || strings.HasPrefix(line, "--- ") ||
+		strings.HasPrefix(line, " * Origin: ")
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || r == '\'' || r == '’'
+}
+
+// lineWords returns rune locations of words in line, words glued to digits,
+// paths, addresses and urls are skipped
+func lineWords(line string) [][2]int {
+	var locs [][2]int
+	r := []rune(line)
+	for i := 0; i < len(r); {
+		if !unicode.IsLetter(r[i]) {
+			i++
+			continue
+		}
+		s := i
+		for i < len(r) && isWordRune(r[i]) {
+			i++
+		}
+		e := i
+		for e > s && !unicode.IsLetter(r[e-1]) {
+			e--
+		}
+		if s > 0 && strings.ContainsRune("0123456789_/\\@.:", r[s-1]) {
+			continue
+		}
+		if i < len(r) && (unicode.IsDigit(r[i]) || strings.ContainsRune("_/\\@", r[i]) ||
+			(i+1 < len(r) && strings.ContainsRune(".:", r[i]) && !unicode.IsSpace(r[i+1]))) {
+			continue
+		}
+		locs = append(locs, [2]int{s, e})
+	}
+	return locs
+}
+
+// misspelled returns rune locations of misspelled words in line
+func misspelled(line string) [][2]int {
+	if Spell == nil || spellSkipLine(line) {
+		return nil
+	}
+	var locs [][2]int
+	r := []rune(line)
+	for _, l := range lineWords(line) {
+		if !Spell.Check(string(r[l[0]:l[1]])) {
+			locs = append(locs, l)
+		}
+	}
+	return locs
+}
+
+// highlightSpell overlays misspelled words on the syntax matches of lines
+func (b *Buffer) highlightSpell(start, end int) {
+	if Spell == nil {
+		return
+	}
+	group := highlight.GetGroup("spell")
+	for i := start; i < end && i < b.LinesNum(); i++ {
+		b.overlayMatch(i, misspelled(b.Line(i)), group)
+	}
+}
+
+// MisspelledWord returns the misspelled word under the cursor, or the next one after it
+func (v *View) MisspelledWord() (Loc, Loc, string, bool) {
+	for y := v.Cursor.Y; y < v.Buf.LinesNum(); y++ {
+		r := []rune(v.Buf.Line(y))
+		for _, l := range misspelled(string(r)) {
+			if y == v.Cursor.Y && l[1] < v.Cursor.X {
+				continue
+			}
+			return Loc{l[0], y}, Loc{l[1], y}, string(r[l[0]:l[1]]), true
+		}
+	}
+	return v.Cursor.Loc, v.Cursor.Loc, "", false
+}
+
+// ReplaceWord replaces misspelled word between start and end with correction
+func (v *View) ReplaceWord(start, end Loc, word string) {
+	if v.Readonly {
+		return
+	}
+	v.Buf.Checkpoint()
+	v.Cursor.ResetSelection()
+	v.Buf.Replace(start, end, word)
+	v.Cursor.GotoLoc(start.Move(Count(word), v.Buf))
+	v.Relocate()
+}
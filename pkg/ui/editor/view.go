@@ -55,6 +55,9 @@ type View struct {
 	// The keybindings
 	bindings KeyBindings
 
+	// FindNext goes backward
+	searchBackward bool
+
 	// The colorscheme
 	colorscheme Colorscheme
 
@@ -152,6 +155,8 @@ func (v *View) OpenBuffer(buf *Buffer) {
 	color-link tearline "bold white"
 	color-link tagline "bold white"
 	color-link kludge "bold black"
+	color-link search "black,yellow"
+	color-link spell "underline red"
 	`))
 }
 
@@ -218,7 +223,7 @@ func (v *View) Relocate() bool {
 // ExecuteActions executes the supplied actions
 func (v *View) ExecuteActions(actions []func(*View) bool) bool {
 	relocate := false
-	readonlyBindingsList := []string{"Delete", "Insert", "Backspace", "Cut", "Play", "Paste", "Move", "Add", "DuplicateLine", "Macro"}
+	readonlyBindingsList := []string{"Delete", "Insert", "Backspace", "Cut", "Play", "Paste", "Move", "Add", "DuplicateLine", "Macro", "Replace"}
 	for _, action := range actions {
 		readonlyBindingsResult := false
 		funcName := ShortFuncName(action)
@@ -268,6 +273,7 @@ func (v *View) HandleEvent(event tcell.Event) {
 					}
 				}
 				if e.Modifiers() == key.modifiers {
+					v.Buf.Checkpoint()
 					for _, c := range v.Buf.cursors {
 						ok := v.SetCursor(c)
 						if !ok {
@@ -287,6 +293,7 @@ func (v *View) HandleEvent(event tcell.Event) {
 		if !isBinding && e.Key() == tcell.KeyRune {
 			// Check viewtype if readonly don't insert a rune (readonly help and log view etc.)
 			if !v.Readonly {
+				v.Buf.Typing()
 				for _, c := range v.Buf.cursors {
 					v.SetCursor(c)
 
@@ -303,6 +310,9 @@ func (v *View) HandleEvent(event tcell.Event) {
 					} else {
 						v.Buf.Insert(v.Cursor.Loc, string(e.Rune()))
 					}
+					if WordWrap {
+						v.wrapLine(v.Cursor.Y)
+					}
 				}
 				v.SetCursor(&v.Buf.Cursor)
 			}
@@ -340,6 +350,7 @@ func (v *View) displayView(screen tcell.Screen) {
 	left := v.leftCol
 	top := v.Topline
 
+	v.cellview.spell = !v.Readonly
 	v.cellview.Draw(v.Buf, v.colorscheme, top, height, left, width-v.lineNumOffset)
 
 	realLineN := top - 1
@@ -365,6 +376,15 @@ func (v *View) displayView(screen tcell.Screen) {
 
 				for _, c := range v.Buf.cursors {
 					v.SetCursor(c)
+					if v.Cursor.HasSelection() &&
+						(char.realLoc.GreaterEqual(v.Cursor.CurSelection[0]) && char.realLoc.LessThan(v.Cursor.CurSelection[1]) ||
+							char.realLoc.LessThan(v.Cursor.CurSelection[0]) && char.realLoc.GreaterEqual(v.Cursor.CurSelection[1])) {
+						// The current character is selected
+						lineStyle = defStyle.Reverse(true)
+						if style, ok := v.colorscheme["selection"]; ok {
+							lineStyle = style
+						}
+					}
 				}
 				v.SetCursor(&v.Buf.Cursor)
 
@@ -0,0 +1,147 @@
+package editor
+
+import (
+	"github.com/askovpen/gossiped/pkg/utils"
+	"strings"
+)
+
+// WordWrap breaks lines at RightMargin while typing
+var WordWrap = true
+
+// RightMargin column of wrapped and reformatted lines
+var RightMargin = 79
+
+// QuotePrefix returns the quote prefix like " AB> " of line,
+// detected the same way as quotes are built for replies
+func QuotePrefix(line string) string {
+	return utils.QuotePrefix(line)
+}
+
+// wrapLine breaks line y at the last space before the margin, the quote
+// prefix is repeated on the new line
+func (v *View) wrapLine(y int) {
+	line := []rune(v.Buf.Line(y))
+	if len(line) <= RightMargin {
+		return
+	}
+	prefix := QuotePrefix(string(line))
+	plen := Count(prefix)
+	at := -1
+	for i := RightMargin; i > plen; i-- {
+		if line[i] == ' ' {
+			at = i
+			break
+		}
+	}
+	if at == -1 {
+		return
+	}
+	cursor := v.Cursor.Loc
+	v.Buf.Replace(Loc{at, y}, Loc{at + 1, y}, "\n"+prefix)
+	if cursor.Y == y && cursor.X > at {
+		v.Cursor.GotoLoc(Loc{cursor.X - at - 1 + plen, y + 1})
+	}
+}
+
+// isParagraphLine reports whether line y continues a paragraph with prefix
+func (v *View) isParagraphLine(y int, prefix string) bool {
+	line := v.Buf.Line(y)
+	if QuotePrefix(line) != prefix || strings.TrimSpace(line[len(prefix):]) == "" {
+		return false
+	}
+	return !strings.HasPrefix(line, "--- ") && !strings.HasPrefix(line, " * Origin: ")
+}
+
+// reflow joins text into lines no longer than RightMargin,
+// first starts the first line after the prefix
+func reflow(words []string, prefix, first string) []string {
+	var lines []string
+	cur := prefix + first
+	empty := true
+	for _, w := range words {
+		if !empty && Count(cur)+1+Count(w) > RightMargin {
+			lines = append(lines, cur)
+			cur, empty = prefix, true
+		}
+		if !empty {
+			cur += " "
+		}
+		cur += w
+		empty = false
+	}
+	return append(lines, cur)
+}
+
+// ReformatParagraph reflows the paragraph under the cursor at RightMargin,
+// keeping the quote prefix on every line
+func (v *View) ReformatParagraph() bool {
+	if v.Readonly {
+		return false
+	}
+	y := v.Cursor.Y
+	prefix := QuotePrefix(v.Buf.Line(y))
+	if !v.isParagraphLine(y, prefix) {
+		return false
+	}
+	start, end := y, y
+	for start > 0 && v.isParagraphLine(start-1, prefix) {
+		start--
+	}
+	for end < v.Buf.LinesNum()-1 && v.isParagraphLine(end+1, prefix) {
+		end++
+	}
+	var words []string
+	for i := start; i <= end; i++ {
+		words = append(words, strings.Fields(v.Buf.Line(i)[len(prefix):])...)
+	}
+	firstLine := v.Buf.Line(start)[len(prefix):]
+	indent := firstLine[:len(firstLine)-len(strings.TrimLeft(firstLine, " \t"))]
+	text := strings.Join(reflow(words, prefix, indent), "\n")
+	endLoc := Loc{Count(v.Buf.Line(end)), end}
+	if v.Buf.Substr(Loc{0, start}, endLoc) != text {
+		v.Cursor.ResetSelection()
+		v.Buf.Replace(Loc{0, start}, endLoc, text)
+	}
+	// continue at the next paragraph, so repeated reformat walks the message
+	next := start + strings.Count(text, "\n") + 1
+	if next >= v.Buf.LinesNum() {
+		next = v.Buf.LinesNum() - 1
+	}
+	v.Cursor.GotoLoc(Loc{0, next})
+	return true
+}
+
+// ReformatQuote reflows the quote block under the cursor at RightMargin,
+// keeping the prefix of every quote level
+func (v *View) ReformatQuote() bool {
+	if v.Readonly {
+		return false
+	}
+	y := v.Cursor.Y
+	if QuotePrefix(v.Buf.Line(y)) == "" {
+		return false
+	}
+	start, end := y, y
+	for start > 0 && QuotePrefix(v.Buf.Line(start-1)) != "" {
+		start--
+	}
+	for end < v.Buf.LinesNum()-1 && QuotePrefix(v.Buf.Line(end+1)) != "" {
+		end++
+	}
+	var lines []string
+	for i := start; i <= end; i++ {
+		lines = append(lines, v.Buf.Line(i))
+	}
+	text := strings.Join(utils.ReformatQuote(lines, RightMargin), "\n")
+	endLoc := Loc{Count(v.Buf.Line(end)), end}
+	if text == "" && end < v.Buf.LinesNum()-1 {
+		// the whole block was dropped, remove its line break too
+		endLoc = Loc{0, end + 1}
+	}
+	if v.Buf.Substr(Loc{0, start}, endLoc) != text {
+		v.Cursor.ResetSelection()
+		v.Buf.Replace(Loc{0, start}, endLoc, text)
+	}
+	v.Cursor.GotoLoc(Loc{0, start})
+	return true
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"errors"
+	"github.com/askovpen/gossiped/pkg/config"
+	"github.com/askovpen/gossiped/pkg/ui/editor"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// externalEditor command line of external editor, empty if disabled
+func externalEditor() []string {
+	if !config.Config.Editor.External {
+		return nil
+	}
+	cmd := config.Config.Editor.Command
+	if cmd == "" {
+		cmd = os.Getenv("EDITOR")
+	}
+	if cmd == "" {
+		cmd = "vi"
+	}
+	return strings.Fields(cmd)
+}
+
+// runExternalEditor edits text in temp file with external editor
+func (a *App) runExternalEditor(cmdline []string, text string) (string, error) {
+	f, err := ioutil.TempFile("", "gossiped-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(text + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command(cmdline[0], append(cmdline[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if !a.App.Suspend(func() { err = cmd.Run() }) {
+		return "", errors.New("can't suspend application")
+	}
+	if err != nil {
+		return "", err
+	}
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	text = strings.Replace(string(b), "\r\n", "\n", -1)
+	return strings.TrimSuffix(text, "\n"), nil
+}
+
+// externalEdit edits message body with external editor and shows save menu,
+// falls back to built-in editor on error
+func (a *App) externalEdit() bool {
+	cmdline := externalEditor()
+	if cmdline == nil {
+		return false
+	}
+	text, err := a.runExternalEditor(cmdline, a.im.buffer.String())
+	if err != nil {
+		log.Print(err)
+		a.sb.SetStatus("External editor: " + err.Error())
+		return false
+	}
+	a.im.buffer = editor.NewBufferFromString(text)
+	a.im.eb.OpenBuffer(a.im.buffer)
+	a.Pages.ShowPage("InsertMsgMenu")
+	return true
+}
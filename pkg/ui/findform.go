@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/askovpen/gossiped/pkg/msgapi"
+	"github.com/askovpen/gossiped/pkg/ui/editor"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"regexp"
+)
+
+// FindForm find and replace form over message text, matches are searched while typing
+func (a *App) FindForm(v *editor.View, replace bool) (string, tview.Primitive, bool, bool) {
+	origin := v.Cursor.Loc
+	form := tview.NewForm()
+	field := func(label string) string {
+		return form.GetFormItemByLabel(label).(*tview.InputField).GetText()
+	}
+	checked := func(label string) bool {
+		return form.GetFormItemByLabel(label).(*tview.Checkbox).IsChecked()
+	}
+	compile := func() *regexp.Regexp {
+		q := &msgapi.SearchQuery{Text: field("Find"), Regexp: checked("Regexp"), CaseSensitive: checked("Case sensitive")}
+		if q.Text == "" {
+			return nil
+		}
+		re, err := q.Compile()
+		if err != nil {
+			a.sb.SetStatus(err.Error())
+			return nil
+		}
+		return re
+	}
+	replacement := func() string {
+		if checked("Regexp") {
+			return field("Replace")
+		}
+		return editor.LiteralReplacement(field("Replace"))
+	}
+	update := func() {
+		// fields are added one by one, skip calls until form is complete
+		if form.GetFormItemByLabel("Backward") == nil {
+			return
+		}
+		re := compile()
+		if !v.Search(re, origin, checked("Backward")) && re != nil {
+			a.sb.SetStatus("Not found")
+		} else {
+			a.sb.SetStatus("")
+		}
+	}
+	closeForm := func() {
+		a.Pages.RemovePage("FindForm")
+		a.App.SetFocus(v)
+	}
+	form.AddInputField("Find", "", 40, nil, func(string) { update() })
+	if replace {
+		form.AddInputField("Replace", "", 40, nil, nil)
+	}
+	form.AddCheckbox("Regexp", false, func(bool) { update() }).
+		AddCheckbox("Case sensitive", false, func(bool) { update() }).
+		AddCheckbox("Backward", false, func(bool) { update() })
+	form.AddButton("Find", closeForm)
+	if replace {
+		form.AddButton("Replace", func() {
+			if !v.ReplaceNext(replacement()) {
+				a.sb.SetStatus("No more matches")
+			}
+		})
+		form.AddButton("All", func() {
+			a.sb.SetStatus(fmt.Sprintf("%d replaced", v.ReplaceAll(replacement())))
+			closeForm()
+		})
+	}
+	cancel := func() {
+		v.Search(nil, origin, false)
+		closeForm()
+	}
+	form.AddButton("Cancel", cancel)
+	form.SetCancelFunc(cancel)
+	title := " Find "
+	height := 11
+	if replace {
+		title = " Replace "
+		height = 13
+	}
+	form.SetBorder(true).
+		SetBorderAttributes(tcell.AttrBold).
+		SetBorderColor(tcell.ColorRed).
+		SetTitle(title).
+		SetTitleColor(tcell.ColorYellow).
+		SetTitleAlign(tview.AlignLeft)
+	layout := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().
+			SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(form, height, 1, true), 60, 1, true).
+		AddItem(nil, 0, 1, false)
+	return "FindForm", layout, true, false
+}
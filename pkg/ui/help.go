@@ -76,6 +76,8 @@ Up           Move selection bar to previous area
 Enter, Right Enter the Reader for the selected area
 ESC          Exit gossipEd, prompt for final decision
 Ctrl-C       Exit immediately, no questions asked
+Ctrl-S       Search messages in current, all or matching areas
+Ctrl-P       Pack JAM/Squish/MSG area, purge by age and count
 <xyz>        Search for areas containing the string xyz`).
 		SetDoneFunc(func() {
 			a.Pages.HidePage("AreaListHelp")
@@ -92,11 +94,18 @@ Del            Delete current/marked message(s), ask first
 Right/Left     Next/Previous message
 Home/End       Display first/last part of current message
 </>            Go to First/Last mesage
+-/+            Go to Parent/First reply in thread
+[/]            Go to Previous/Next reply to the same message
 Ctrl-G         Go to message number
 F3, Ctrl-Q     Quote-Reply to message. (Reply to FROM name)
 Ctrl-N         Quote-Reply in another area
 Ctrl-L         Enter the Message Lister
+  t, Ctrl-T    Lister: toggle thread tree
+  Left/Right   Lister: collapse/expand thread, Space toggles
 Ctrl-F         Forward message to another area
+Ctrl-E         Export message to outbound packet
+Ctrl-S, /      Find text in message, incremental
+n/N            Find next/previous match
 `).
 		SetDoneFunc(func() {
 			a.Pages.HidePage("ViewMsgHelp")
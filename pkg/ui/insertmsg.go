@@ -8,7 +8,7 @@ import (
 	"github.com/askovpen/gossiped/pkg/ui/editor"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
-	//"log"
+	"log"
 )
 
 const (
@@ -39,7 +39,22 @@ func (a *App) InsertMsgMenu() (string, tview.Primitive, bool, bool) {
 			case 0:
 				//a.im.newMsg.Body = a.im.eb.GetText(false)
 				a.im.newMsg.Body = a.im.buffer.String()
-				msgapi.Areas[a.im.postArea].SaveMsg(a.im.newMsg.MakeBody())
+				msg := a.im.newMsg.MakeBody()
+				// SaveMsg encodes message to area charset, msg is kept for retry and export
+				saved := *msg
+				if err := msgapi.Areas[a.im.postArea].SaveMsg(&saved); err != nil {
+					log.Print(err)
+					a.sb.SetStatus("Not saved: " + err.Error())
+					a.Pages.HidePage("InsertMsgMenu")
+					a.App.SetFocus(a.im.eb)
+					return
+				}
+				if config.Config.Outbound.Path != "" && msgapi.CanExport(a.im.postArea) {
+					if _, err := msgapi.ExportPkt(msg); err != nil {
+						log.Print(err)
+						a.sb.SetStatus("Saved, not exported: " + err.Error())
+					}
+				}
 				a.Pages.HidePage("InsertMsgMenu")
 				a.Pages.RemovePage("InsertMsgMenu")
 				a.Pages.SwitchToPage(fmt.Sprintf("ViewMsg-%s-%d", msgapi.Areas[a.im.curArea].GetName(), msgapi.Areas[a.im.curArea].GetLast()))
@@ -54,6 +69,7 @@ func (a *App) InsertMsgMenu() (string, tview.Primitive, bool, bool) {
 			case 2:
 				a.Pages.HidePage("InsertMsgMenu")
 				a.App.SetFocus(a.im.eb)
+				a.externalEdit()
 			case 3:
 				a.Pages.HidePage("InsertMsgMenu")
 				a.App.SetFocus(a.im.eh)
@@ -77,17 +93,23 @@ func (a *App) InsertMsg(areaID int, msgType int) (string, tview.Primitive, bool,
 	if msgapi.Areas[a.im.postArea].GetChrs() != "" {
 		a.im.newMsg.Kludges["CHRS:"] = msgapi.Areas[a.im.postArea].GetChrs()
 	}
+	a.im.newMsg.Attrs = []string{"Loc"}
+	if msgapi.Areas[a.im.postArea].GetType() == msgapi.EchoAreaTypeNetmail {
+		a.im.newMsg.Attrs = []string{"Pvt", "Loc"}
+	}
 	if msgapi.Areas[a.im.postArea].GetType() != msgapi.EchoAreaTypeNetmail && (a.im.newMsgType == 0 || a.im.newMsgType == newMsgTypeForward) {
 		a.im.newMsg.To = "All"
 	}
 	if (a.im.newMsgType&newMsgTypeAnswer) != 0 || (a.im.newMsgType&newMsgTypeAnswerNewArea) != 0 {
 		omsg, _ = msgapi.Areas[areaID].GetMsg(msgapi.Areas[a.im.curArea].GetLast())
+		omsg.AreaID = areaID
 		a.im.newMsg.To = omsg.From
 		a.im.newMsg.ToAddr = omsg.FromAddr
 		a.im.newMsg.Kludges["REPLY:"] = omsg.Kludges["MSGID:"]
 		a.im.newMsg.Subject = omsg.Subject
 	} else if (a.im.newMsgType & newMsgTypeForward) != 0 {
 		omsg, _ = msgapi.Areas[areaID].GetMsg(msgapi.Areas[a.im.curArea].GetLast())
+		omsg.AreaID = areaID
 		a.im.newMsg.Subject = omsg.Subject
 	}
 	a.im.eh = NewEditHeader(a.im.newMsg)
@@ -103,6 +125,24 @@ func (a *App) InsertMsg(areaID int, msgType int) (string, tview.Primitive, bool,
 		a.Pages.ShowPage("InsertMsgMenu")
 		//			//log.Printf("%q",a.App.GetFocus())
 	})
+	a.im.eb.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyCtrlS {
+			a.Pages.AddPage(a.FindForm(a.im.eb, true))
+			a.Pages.ShowPage("FindForm")
+			return nil
+		}
+		if event.Key() == tcell.KeyF7 && editor.Spell != nil {
+			start, end, word, ok := a.im.eb.MisspelledWord()
+			if !ok {
+				a.sb.SetStatus("No misspelled words")
+				return nil
+			}
+			a.Pages.AddPage(a.SpellMenu(a.im.eb, start, end, word))
+			a.Pages.ShowPage("SpellMenu")
+			return nil
+		}
+		return event
+	})
 	a.im.eh.SetDoneFunc(func(r [5][]rune) {
 		a.im.newMsg.From = string(r[0])
 		a.im.newMsg.FromAddr = types.AddrFromString(string(r[1]))
@@ -128,10 +168,11 @@ func (a *App) InsertMsg(areaID int, msgType int) (string, tview.Primitive, bool,
 			a.im.eb.SetText(mv, p)
 		}*/
 		a.App.SetFocus(a.im.eb)
+		a.externalEdit()
 	})
 	layout := tview.NewFlex().
 		SetDirection(tview.FlexRow).
-		AddItem(a.im.eh, 6, 1, true).
+		AddItem(a.im.eh, a.im.eh.Height(), 1, true).
 		AddItem(a.im.eb, 0, 1, false)
 	return fmt.Sprintf("InsertMsg-%s", msgapi.Areas[areaID].GetName()), layout, true, true
 }
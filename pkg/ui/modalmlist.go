@@ -7,6 +7,7 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"strconv"
+	"strings"
 )
 
 // ModalMessageList is a centered message window used to inform the user or prompt them
@@ -20,6 +21,10 @@ type ModalMessageList struct {
 	frame     *tview.Frame
 	textColor tcell.Color
 	done      func(msgNum uint32)
+	areaID    int
+	threaded  bool
+	collapsed map[uint32]bool
+	rows      []uint32
 }
 
 // NewModalMessageList returns a new modal message window.
@@ -27,19 +32,30 @@ func NewModalMessageList(areaID int) *ModalMessageList {
 	m := &ModalMessageList{
 		Box:       tview.NewBox(),
 		textColor: tview.Styles.PrimaryTextColor,
+		areaID:    areaID,
+		collapsed: make(map[uint32]bool),
 	}
 	m.table = tview.NewTable().
 		SetFixed(1, 0).
 		SetSelectable(true, false).
 		SetSelectedStyle(tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorNavy).Bold(true)).
 		SetSelectedFunc(func(row int, column int) {
-			m.done(uint32(row))
+			if row > 0 && row <= len(m.rows) {
+				m.done(m.rows[row-1])
+			}
 		})
 	m.frame = tview.NewFrame(m.table).SetBorders(0, 0, 1, 0, 0, 0)
 	m.frame.SetTitle("List Messages")
 	m.frame.SetBorder(true).
 		SetBackgroundColor(tcell.ColorBlack).
 		SetBorderPadding(0, 0, 1, 1).SetBorderColor(tcell.ColorRed).SetBorderAttributes(tcell.AttrBold).SetTitleColor(tcell.ColorYellow).SetTitleAlign(tview.AlignLeft)
+	m.fill(msgapi.Areas[areaID].GetLast())
+	return m
+}
+
+// fill render table, flat or threaded, and select msgNum
+func (m *ModalMessageList) fill(msgNum uint32) {
+	m.table.Clear()
 	m.table.SetCell(
 		0, 0, tview.NewTableCell(" Msg ").
 			SetTextColor(tcell.ColorYellow).
@@ -68,11 +84,55 @@ func NewModalMessageList(areaID int) *ModalMessageList {
 			SetAttributes(tcell.AttrBold).
 			SetSelectable(false).
 			SetAlign(tview.AlignRight))
-	for i, mh := range *msgapi.Areas[areaID].GetMessages() {
+	messages := *msgapi.Areas[m.areaID].GetMessages()
+	byNum := make(map[uint32]msgapi.MessageListItem)
+	for _, mh := range messages {
+		byNum[mh.MsgNum] = mh
+	}
+	m.rows = m.rows[:0]
+	prefix := make(map[uint32]string)
+	if m.threaded {
+		m.frame.SetTitle("List Messages (Threads)")
+		thread := msgapi.GetThread(msgapi.Areas[m.areaID])
+		var walk func(n uint32, depth int)
+		walk = func(n uint32, depth int) {
+			m.rows = append(m.rows, n)
+			children := thread.Children(n)
+			mark := "  "
+			if len(children) > 0 && m.collapsed[n] {
+				mark = "+ "
+			} else if len(children) > 0 {
+				mark = "- "
+			}
+			prefix[n] = strings.Repeat("  ", depth) + mark
+			if m.collapsed[n] {
+				return
+			}
+			for _, c := range children {
+				walk(c, depth+1)
+			}
+		}
+		for _, mh := range messages {
+			if thread.Parent(mh.MsgNum) == 0 {
+				walk(mh.MsgNum, 0)
+			}
+		}
+	} else {
+		m.frame.SetTitle("List Messages")
+		for _, mh := range messages {
+			m.rows = append(m.rows, mh.MsgNum)
+		}
+	}
+	selected := 0
+	for i, n := range m.rows {
+		mh := byNum[n]
 		ch := " "
-		if i == int(msgapi.Areas[areaID].GetLast()-1) {
+		if mh.MsgNum == msgapi.Areas[m.areaID].GetLast() {
 			ch = "[::b],"
 		}
+		if mh.MsgNum == msgNum {
+			selected = i + 1
+		}
 		//mh.From, mh.To, mh.Subject, mh.DateWritten.Format("02 Jan 06"))
 		m.table.SetCell(i+1, 0, tview.NewTableCell(strconv.FormatInt(int64(mh.MsgNum), 10)+ch).SetAlign(tview.AlignRight).SetTextColor(tcell.ColorSilver))
 		if utils.NamesEqual(mh.From, config.Config.Username) {
@@ -85,11 +145,19 @@ func NewModalMessageList(areaID int) *ModalMessageList {
 		} else {
 			m.table.SetCell(i+1, 2, tview.NewTableCell(mh.To).SetTextColor(tcell.ColorSilver))
 		}
-		m.table.SetCell(i+1, 3, tview.NewTableCell(mh.Subject).SetTextColor(tcell.ColorSilver))
+		m.table.SetCell(i+1, 3, tview.NewTableCell(prefix[mh.MsgNum]+mh.Subject).SetTextColor(tcell.ColorSilver))
 		m.table.SetCell(i+1, 4, tview.NewTableCell(mh.DateWritten.Format("02 Jan 06")).SetTextColor(tcell.ColorSilver))
 	}
-	m.table.Select(int(msgapi.Areas[areaID].GetLast()), 0)
-	return m
+	m.table.Select(selected, 0)
+}
+
+// selected return message number under selection bar
+func (m *ModalMessageList) selected() uint32 {
+	row, _ := m.table.GetSelection()
+	if row > 0 && row <= len(m.rows) {
+		return m.rows[row-1]
+	}
+	return 0
 }
 
 // SetTextColor sets the color of the message text.
@@ -149,6 +217,27 @@ func (m *ModalMessageList) Draw(screen tcell.Screen) {
 func (m *ModalMessageList) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
 	return m.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
 		if m.HasFocus() {
+			if event.Key() == tcell.KeyCtrlT || event.Rune() == 't' {
+				m.threaded = !m.threaded
+				m.fill(m.selected())
+				return
+			}
+			if m.threaded && (event.Key() == tcell.KeyLeft || event.Key() == tcell.KeyRight || event.Rune() == ' ') {
+				root := msgapi.GetThread(msgapi.Areas[m.areaID]).Root(m.selected())
+				if event.Key() == tcell.KeyLeft {
+					m.collapsed[root] = true
+				} else if event.Key() == tcell.KeyRight {
+					delete(m.collapsed, root)
+				} else {
+					m.collapsed[root] = !m.collapsed[root]
+				}
+				if m.collapsed[root] {
+					m.fill(root)
+				} else {
+					m.fill(m.selected())
+				}
+				return
+			}
 			if handler := m.table.InputHandler(); handler != nil {
 				handler(event, setFocus)
 			}
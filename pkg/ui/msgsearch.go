@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"fmt"
+	"github.com/askovpen/gossiped/pkg/msgapi"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"strconv"
+)
+
+// SearchForm search parameters form
+func (a *App) SearchForm(areaID int) (string, tview.Primitive, bool, bool) {
+	form := tview.NewForm().
+		AddInputField("Text", "", 40, nil, nil).
+		AddCheckbox("Regexp", false, nil).
+		AddCheckbox("Case sensitive", false, nil).
+		AddDropDown("Areas", []string{"Current", "All", "Mask"}, 0, nil).
+		AddInputField("Area mask", msgapi.Areas[areaID].GetName(), 40, nil, nil)
+	cancel := func() {
+		a.Pages.RemovePage("SearchForm")
+		a.App.SetFocus(a.al)
+	}
+	form.AddButton("Search", func() {
+		q := &msgapi.SearchQuery{
+			Text:          form.GetFormItemByLabel("Text").(*tview.InputField).GetText(),
+			Regexp:        form.GetFormItemByLabel("Regexp").(*tview.Checkbox).IsChecked(),
+			CaseSensitive: form.GetFormItemByLabel("Case sensitive").(*tview.Checkbox).IsChecked(),
+		}
+		if q.Text == "" {
+			return
+		}
+		switch opt, _ := form.GetFormItemByLabel("Areas").(*tview.DropDown).GetCurrentOption(); opt {
+		case 0:
+			q.Areas = []int{areaID}
+		case 2:
+			q.Areas = msgapi.AreasByMask(form.GetFormItemByLabel("Area mask").(*tview.InputField).GetText())
+			if len(q.Areas) == 0 {
+				a.sb.SetStatus("No areas match mask")
+				return
+			}
+		}
+		if _, err := q.Compile(); err != nil {
+			a.sb.SetStatus(err.Error())
+			return
+		}
+		a.Pages.RemovePage("SearchForm")
+		a.stopSearch()
+		a.Pages.RemovePage("SearchResults")
+		a.Pages.AddPage(a.SearchResults(q))
+		a.Pages.SwitchToPage("SearchResults")
+	})
+	form.AddButton("Cancel", cancel)
+	form.SetCancelFunc(cancel)
+	form.SetBorder(true).
+		SetBorderAttributes(tcell.AttrBold).
+		SetBorderColor(tcell.ColorRed).
+		SetTitle(" Search ").
+		SetTitleColor(tcell.ColorYellow).
+		SetTitleAlign(tview.AlignLeft)
+	layout := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().
+			SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(form, 15, 1, true).
+			AddItem(nil, 0, 1, false), 60, 1, true).
+		AddItem(nil, 0, 1, false)
+	return "SearchForm", layout, true, false
+}
+
+// stopSearch stop running search, if any
+func (a *App) stopSearch() {
+	if a.searchStop != nil {
+		close(a.searchStop)
+		a.searchStop = nil
+	}
+}
+
+// SearchResults run search, streaming hits into result table
+func (a *App) SearchResults(q *msgapi.SearchQuery) (string, tview.Primitive, bool, bool) {
+	re, _ := q.Compile()
+	var hits []msgapi.SearchHit
+	stop := make(chan struct{})
+	a.searchStop = stop
+	table := tview.NewTable().
+		SetFixed(1, 0).
+		SetSelectable(true, false).
+		SetSelectedStyle(tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorNavy).Bold(true))
+	table.SetBorder(true).
+		SetBorderAttributes(tcell.AttrBold).
+		SetBorderColor(tcell.ColorBlue).
+		SetTitle(" Search: " + q.Text + " ").
+		SetTitleColor(tcell.ColorYellow).
+		SetTitleAlign(tview.AlignLeft)
+	for i, h := range []string{"Area", "Msg", "From", "Subj", "Line"} {
+		cell := tview.NewTableCell(h).
+			SetTextColor(tcell.ColorYellow).
+			SetAttributes(tcell.AttrBold).
+			SetSelectable(false)
+		if i == 4 {
+			cell.SetExpansion(1)
+		}
+		table.SetCell(0, i, cell)
+	}
+	table.SetSelectedFunc(func(row int, column int) {
+		if row < 1 || row > len(hits) {
+			return
+		}
+		h := hits[row-1]
+		a.search = re
+		a.Pages.AddPage(a.ViewMsg(h.AreaID, h.MsgNum))
+		a.Pages.SwitchToPage(fmt.Sprintf("ViewMsg-%s-%d", msgapi.Areas[h.AreaID].GetName(), h.MsgNum))
+	})
+	table.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			a.stopSearch()
+			a.Pages.SwitchToPage("AreaList")
+			a.Pages.RemovePage("SearchResults")
+		}
+	})
+	go func() {
+		err := msgapi.SearchMessages(q, stop,
+			func(h msgapi.SearchHit) {
+				a.App.QueueUpdateDraw(func() {
+					hits = append(hits, h)
+					row := len(hits)
+					table.SetCell(row, 0, tview.NewTableCell(msgapi.Areas[h.AreaID].GetName()).SetTextColor(tcell.ColorSilver))
+					table.SetCell(row, 1, tview.NewTableCell(strconv.FormatInt(int64(h.MsgNum), 10)).SetAlign(tview.AlignRight).SetTextColor(tcell.ColorSilver))
+					table.SetCell(row, 2, tview.NewTableCell(h.From).SetTextColor(tcell.ColorSilver))
+					table.SetCell(row, 3, tview.NewTableCell(h.Subject).SetTextColor(tcell.ColorSilver))
+					table.SetCell(row, 4, tview.NewTableCell(h.Line).SetTextColor(tcell.ColorSilver))
+				})
+			},
+			func(areaID int) {
+				a.App.QueueUpdateDraw(func() {
+					a.sb.SetStatus(fmt.Sprintf("Searching %s...", msgapi.Areas[areaID].GetName()))
+				})
+			})
+		a.App.QueueUpdateDraw(func() {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err != nil {
+				a.sb.SetStatus(err.Error())
+			} else {
+				a.sb.SetStatus(fmt.Sprintf("Search done, %d found", len(hits)))
+			}
+		})
+	}()
+	return "SearchResults", table, true, true
+}
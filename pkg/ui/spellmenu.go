@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"github.com/askovpen/gossiped/pkg/ui/editor"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// SpellMenu suggestions popup for misspelled word between start and end
+func (a *App) SpellMenu(v *editor.View, start, end editor.Loc, word string) (string, tview.Primitive, bool, bool) {
+	v.Cursor.GotoLoc(start)
+	v.Relocate()
+	closeMenu := func() {
+		a.Pages.RemovePage("SpellMenu")
+		a.App.SetFocus(v)
+	}
+	list := tview.NewList().
+		ShowSecondaryText(false).
+		SetDoneFunc(closeMenu)
+	for _, s := range editor.Spell.Suggest(word) {
+		s := s
+		list.AddItem(s, "", 0, func() {
+			v.ReplaceWord(start, end, s)
+			closeMenu()
+		})
+	}
+	list.AddItem("[::d]Ignore", "", 0, func() {
+		editor.Spell.Add(word)
+		closeMenu()
+	})
+	list.SetBorder(true).
+		SetBorderAttributes(tcell.AttrBold).
+		SetBorderColor(tcell.ColorRed).
+		SetTitle(" " + word + " ").
+		SetTitleColor(tcell.ColorYellow).
+		SetTitleAlign(tview.AlignLeft)
+	layout := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().
+			SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(list, list.GetItemCount()+2, 1, true).
+			AddItem(nil, 0, 1, false), 40, 1, true).
+		AddItem(nil, 0, 1, false)
+	return "SpellMenu", layout, true, false
+}
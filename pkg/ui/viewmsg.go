@@ -28,6 +28,15 @@ func (a *App) ViewMsg(areaID int, msgNum uint32) (string, tview.Primitive, bool,
 			msgNum = 1
 		}
 		msgapi.Areas[areaID].SetLast(msgNum)
+		// reply links are shown once thread is built by -/+/[/] navigation
+		if thread := msgapi.CachedThread(msgapi.Areas[areaID]); thread != nil {
+			if p := thread.Parent(msgNum); p > 0 {
+				msg.ReplyTo = p
+			}
+			if c := thread.Children(msgNum); len(c) > 0 {
+				msg.Replies = c
+			}
+		}
 	}
 	if msgapi.Areas[areaID].GetCount()-msgapi.Areas[areaID].GetLast() > 0 {
 		a.al.SetCell(areaID+1, 0, tview.NewTableCell(strconv.FormatInt(int64(areaID), 10)+"[::b]+").SetAlign(tview.AlignRight))
@@ -54,6 +63,16 @@ func (a *App) ViewMsg(areaID int, msgNum uint32) (string, tview.Primitive, bool,
 	} else {
 		body = editor.NewView(editor.NewBufferFromString(""))
 	}
+	if a.search != nil {
+		body.Buf.SetSearch(a.search)
+		if loc, ok := body.Buf.FindNext(editor.Loc{}); ok {
+			body.Cursor.GotoLoc(loc)
+			if loc.Y > 2 {
+				body.Topline = loc.Y - 2
+			}
+		}
+		a.search = nil
+	}
 	header.SetDoneFunc(func(s string) {
 		num, _ := strconv.ParseUint(s, 10, 32)
 		if uint32(num) >= msgapi.Areas[areaID].GetCount() {
@@ -73,10 +92,22 @@ func (a *App) ViewMsg(areaID int, msgNum uint32) (string, tview.Primitive, bool,
 	body.Readonly = true
 	body.SetDoneFunc(func() {
 		//		if key == tcell.KeyEscape {
-		a.Pages.SwitchToPage("AreaList")
+		if a.Pages.HasPage("SearchResults") {
+			a.Pages.SwitchToPage("SearchResults")
+		} else {
+			a.Pages.SwitchToPage("AreaList")
+		}
 		a.Pages.RemovePage(fmt.Sprintf("ViewMsg-%s-%d", msgapi.Areas[areaID].GetName(), msgNum))
 		//		}
 	})
+	gotoMsg := func(n uint32) {
+		if n == 0 || n == msgNum {
+			return
+		}
+		a.Pages.AddPage(a.ViewMsg(areaID, n))
+		a.Pages.SwitchToPage(fmt.Sprintf("ViewMsg-%s-%d", msgapi.Areas[areaID].GetName(), n))
+		a.Pages.RemovePage(fmt.Sprintf("ViewMsg-%s-%d", msgapi.Areas[areaID].GetName(), msgNum))
+	}
 	body.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		if event.Key() == tcell.KeyF1 {
 			a.Pages.AddPage(a.ViewMsgHelp())
@@ -108,12 +139,20 @@ func (a *App) ViewMsg(areaID int, msgNum uint32) (string, tview.Primitive, bool,
 					a.Pages.RemovePage(fmt.Sprintf("ViewMsg-%s-%d", msgapi.Areas[areaID].GetName(), msgNum))
 				}
 			}
+		} else if msgapi.Areas[areaID].GetMsgType() == msgapi.EchoAreaMsgTypePKT && (event.Key() == tcell.KeyInsert ||
+			event.Key() == tcell.KeyCtrlI || event.Key() == tcell.KeyDelete || event.Key() == tcell.KeyCtrlQ ||
+			event.Key() == tcell.KeyF3 || event.Rune() == 'q') {
+			a.sb.SetStatus("Read-only area")
 		} else if event.Key() == tcell.KeyInsert || event.Key() == tcell.KeyCtrlI {
 			a.Pages.AddPage(a.InsertMsg(areaID, 0))
 			a.Pages.AddPage(a.InsertMsgMenu())
 			a.Pages.SwitchToPage(fmt.Sprintf("InsertMsg-%s", msgapi.Areas[areaID].GetName()))
 		} else if msg == nil {
 			return event
+		} else if event.Key() == tcell.KeyCtrlS || event.Rune() == '/' {
+			a.Pages.AddPage(a.FindForm(body, false))
+			a.Pages.ShowPage("FindForm")
+			return nil
 		} else if event.Key() == tcell.KeyCtrlK || (event.Rune() == 'k' && event.Modifiers()&tcell.ModAlt > 0) {
 			a.showKludges = !a.showKludges
 			//body.SetText(msg.ToView(a.showKludges))
@@ -128,9 +167,25 @@ func (a *App) ViewMsg(areaID int, msgNum uint32) (string, tview.Primitive, bool,
 		} else if event.Key() == tcell.KeyCtrlF || (event.Rune() == 'f' && event.Modifiers()&tcell.ModAlt > 0) {
 			a.Pages.AddPage(a.showAreaList(areaID, newMsgTypeForward))
 			a.Pages.ShowPage("AreaListModal")
+		} else if event.Rune() == 'n' {
+			body.FindNext()
+			return nil
+		} else if event.Rune() == 'N' {
+			body.FindPrevious()
+			return nil
 		} else if event.Key() == tcell.KeyDelete {
 			a.Pages.AddPage(a.showDelMsg(areaID, msgNum))
 			a.Pages.ShowPage("DelMsgModal")
+		} else if event.Key() == tcell.KeyCtrlE || (event.Rune() == 'e' && event.Modifiers()&tcell.ModAlt > 0) {
+			em := *msg
+			em.AreaID = areaID
+			em.Kludges = nil
+			fn, err := msgapi.ExportPkt(&em)
+			if err != nil {
+				a.sb.SetStatus(err.Error())
+			} else {
+				a.sb.SetStatus("Exported to " + fn)
+			}
 		} else if event.Key() == tcell.KeyCtrlL || event.Rune() == 'l' {
 			a.Pages.AddPage(a.showMessageList(areaID))
 			a.Pages.ShowPage("MessageListModal")
@@ -138,6 +193,22 @@ func (a *App) ViewMsg(areaID int, msgNum uint32) (string, tview.Primitive, bool,
 			a.App.SetFocus(header)
 			//a.Pages.AddPage(a.showMessageList(areaID))
 			//a.Pages.ShowPage("MessageListModal")
+		} else if event.Rune() == '-' {
+			gotoMsg(msgapi.GetThread(msgapi.Areas[areaID]).Parent(msgNum))
+		} else if event.Rune() == '+' {
+			if c := msgapi.GetThread(msgapi.Areas[areaID]).Children(msgNum); len(c) > 0 {
+				gotoMsg(c[0])
+			}
+		} else if event.Rune() == '[' || event.Rune() == ']' {
+			thread := msgapi.GetThread(msgapi.Areas[areaID])
+			siblings := thread.Children(thread.Parent(msgNum))
+			for i, sn := range siblings {
+				if sn == msgNum && event.Rune() == '[' && i > 0 {
+					gotoMsg(siblings[i-1])
+				} else if sn == msgNum && event.Rune() == ']' && i < len(siblings)-1 {
+					gotoMsg(siblings[i+1])
+				}
+			}
 		} else if event.Rune() == '<' {
 			if msgNum != 1 {
 				a.Pages.AddPage(a.ViewMsg(areaID, 1))
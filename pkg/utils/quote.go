@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var quoteRE = regexp.MustCompile(">+")
+
+// QuotePrefix returns quote prefix like " AB> " of line, including one trailing space
+func QuotePrefix(line string) string {
+	ind := quoteRE.FindStringIndex(line)
+	if ind == nil || ind[0] >= 6 {
+		return ""
+	}
+	if lt := strings.Index(line, "<"); lt != -1 && lt < ind[1] {
+		return ""
+	}
+	if ind[1] < len(line) && line[ind[1]] == ' ' {
+		ind[1]++
+	}
+	return line[:ind[1]]
+}
+
+// QuoteLevel returns nesting level of quote prefix
+func QuoteLevel(prefix string) int {
+	return strings.Count(prefix, ">")
+}
+
+// isServiceLine reports kludge, SEEN-BY, tearline and origin lines
+func isServiceLine(text string) bool {
+	return strings.HasPrefix(text, "\x01") ||
+		strings.HasPrefix(text, "SEEN-BY: ") ||
+		text == "---" || strings.HasPrefix(text, "--- ") ||
+		strings.HasPrefix(text, " * Origin: ")
+}
+
+type quoteLine struct {
+	prefix, text string
+}
+
+func (q quoteLine) String() string {
+	if q.text == "" {
+		return strings.TrimRight(q.prefix, " ")
+	}
+	return q.prefix + q.text
+}
+
+// continues reports whether next line is continuation of soft wrapped paragraph line
+func (q quoteLine) continues(next quoteLine, margin int) bool {
+	if q.text == "" || next.prefix != q.prefix || next.text == "" || QuoteLevel(q.prefix) == 0 {
+		return false
+	}
+	if strings.TrimLeft(next.text, " \t") != next.text {
+		return false
+	}
+	first := strings.Fields(next.text)[0]
+	return utf8.RuneCountInString(q.String())+1+utf8.RuneCountInString(first) > margin
+}
+
+// wrapQuote joins words into lines with prefix no longer than margin
+func wrapQuote(prefix, indent string, words []string, margin int) []string {
+	var lines []string
+	cur := prefix + indent
+	empty := true
+	for _, w := range words {
+		if !empty && utf8.RuneCountInString(cur)+1+utf8.RuneCountInString(w) > margin {
+			lines = append(lines, cur)
+			cur, empty = prefix, true
+		}
+		if !empty {
+			cur += " "
+		}
+		cur += w
+		empty = false
+	}
+	return append(lines, cur)
+}
+
+// ReformatQuote rewraps quoted paragraphs within margin, keeping prefix of each
+// quote level. Kludge, tearline and origin lines and empty trailing quotes are dropped,
+// unquoted lines are kept as is
+func ReformatQuote(lines []string, margin int) []string {
+	var ql []quoteLine
+	for _, l := range lines {
+		l = strings.TrimRight(l, " \t\r")
+		prefix := QuotePrefix(l)
+		text := l[len(prefix):]
+		if isServiceLine(l) || (prefix != "" && isServiceLine(text)) {
+			continue
+		}
+		ql = append(ql, quoteLine{prefix, text})
+	}
+	for len(ql) > 0 && ql[len(ql)-1].prefix != "" && ql[len(ql)-1].text == "" {
+		ql = ql[:len(ql)-1]
+	}
+	var res []string
+	for i := 0; i < len(ql); i++ {
+		q := ql[i]
+		if QuoteLevel(q.prefix) == 0 || q.text == "" {
+			res = append(res, q.String())
+			continue
+		}
+		indent := q.text[:len(q.text)-len(strings.TrimLeft(q.text, " \t"))]
+		words := strings.Fields(q.text)
+		for ; i+1 < len(ql) && ql[i].continues(ql[i+1], margin); i++ {
+			words = append(words, strings.Fields(ql[i+1].text)...)
+		}
+		res = append(res, wrapQuote(q.prefix, indent, words, margin)...)
+	}
+	return res
+}
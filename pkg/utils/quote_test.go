@@ -0,0 +1,60 @@
+package utils
+
+import (
+	. "github.com/franela/goblin"
+	"strings"
+	"testing"
+)
+
+func TestReformatQuote(t *testing.T) {
+	g := Goblin(t)
+	g.Describe("Check ReformatQuote()", func() {
+		g.It("quote prefix", func() {
+			g.Assert(QuotePrefix(" AB> text")).Equal(" AB> ")
+			g.Assert(QuotePrefix(" AB>> text")).Equal(" AB>> ")
+			g.Assert(QuotePrefix("<a> text")).Equal("")
+			g.Assert(QuotePrefix("long text > 1")).Equal("")
+			g.Assert(QuoteLevel(" AB>> ")).Equal(2)
+		})
+		g.It("rewrap overlong lines", func() {
+			res := ReformatQuote([]string{
+				" AB> one two three four five",
+				" AB> six",
+				" AB>",
+				" AB> seven",
+			}, 20)
+			g.Assert(strings.Join(res, "|")).Equal(" AB> one two three|" +
+				" AB> four five six|" +
+				" AB>|" +
+				" AB> seven")
+		})
+		g.It("keep short lines and levels", func() {
+			res := ReformatQuote([]string{
+				" AB>> old quote which is long",
+				" AB> Regards,",
+				" AB> John",
+				"plain text which is not a quote at all",
+			}, 20)
+			g.Assert(strings.Join(res, "|")).Equal(" AB>> old quote|" +
+				" AB>> which is long|" +
+				" AB> Regards,|" +
+				" AB> John|" +
+				"plain text which is not a quote at all")
+		})
+		g.It("keep indent of paragraph", func() {
+			res := ReformatQuote([]string{" AB>   - item", " AB>   - item"}, 20)
+			g.Assert(strings.Join(res, "|")).Equal(" AB>   - item| AB>   - item")
+		})
+		g.It("drop service lines and trailing empty quotes", func() {
+			res := ReformatQuote([]string{
+				" AB> text",
+				" AB> \x01MSGID: 1:2/3 12345678",
+				" AB> --- GoldED+",
+				" AB>  * Origin: home (1:2/3)",
+				" AB> ",
+				" AB>",
+			}, 20)
+			g.Assert(strings.Join(res, "|")).Equal(" AB> text")
+		})
+	})
+}